@@ -0,0 +1,100 @@
+// Command pdf-freezer-counterd serves a shared serial number counter so
+// several pdf-freezer workstations issue numbers from one sequence.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"pdf-freezer/internal/config"
+	"pdf-freezer/internal/counter"
+)
+
+func main() {
+	defaultDir := ""
+	if configDir, err := os.UserConfigDir(); err == nil {
+		defaultDir = filepath.Join(configDir, "pdf-freezer-counterd")
+	}
+
+	addr := flag.String("listen", "localhost:8765", "address to listen on")
+	dir := flag.String("dir", defaultDir, "directory holding the counter data")
+	backend := flag.String("backend", counter.BackendFile, "storage backend: file or sqlite")
+	reset := flag.String("reset", counter.ResetNever, "automatic reset of all series: never, yearly or monthly")
+	fiscalYearStart := flag.Int("fiscal-year-start", 1, "month (1-12) a yearly reset period starts in")
+	acknowledge := flag.String("acknowledge", "", "accept a counter state that failed its integrity check, giving the reason, and exit")
+	flag.Parse()
+
+	// Read the secrets from the environment so they do not show up in the
+	// process list
+	token := os.Getenv("PDF_FREEZER_COUNTER_TOKEN")
+	if token == "" && !isLoopback(*addr) {
+		log.Fatalf("PDF_FREEZER_COUNTER_TOKEN must be set to listen on %s; without a token only localhost is allowed", *addr)
+	}
+	pinHash := os.Getenv("PDF_FREEZER_COUNTER_PIN_HASH")
+	if pinHash == "" {
+		log.Printf("PDF_FREEZER_COUNTER_PIN_HASH not set, counter overrides are refused")
+	}
+
+	location := *dir
+	switch *backend {
+	case counter.BackendFile:
+	case counter.BackendSQLite:
+		location = filepath.Join(*dir, "counter.db")
+	default:
+		log.Fatalf("Unsupported backend %q for the counter server", *backend)
+	}
+	store, err := counter.OpenStore(counter.StoreConfig{Backend: *backend, Location: location})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m := counter.NewManagerWithStore(store)
+	if *acknowledge != "" {
+		if err := m.AcknowledgeTamper(*acknowledge); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Integrity alert acknowledged")
+		return
+	}
+	if err := m.SetResetPolicy(counter.ResetPolicy{Period: *reset, FiscalYearStart: *fiscalYearStart}); err != nil {
+		log.Fatal(err)
+	}
+	m.OnReset = func(e counter.Event) {
+		log.Printf("Series %s reset for period %s (was at %d)", e.Series, e.Period, e.Previous)
+	}
+
+	handler := counter.NewServer(m, token)
+	if pinHash != "" {
+		handler.CheckPIN = func(pin string) bool { return config.VerifyPIN(pinHash, pin) }
+	}
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	log.Printf("Counter server listening on %s (%s backend in %s)", *addr, *backend, *dir)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// isLoopback reports whether addr only accepts connections from this host
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,66 @@
+// @ts-check
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Create as $Create } from "@wailsio/runtime";
+
+/**
+ * BatchResult is the outcome of one file of a batch
+ */
+export class BatchResult {
+    /**
+     * Creates a new BatchResult instance.
+     * @param {Partial<BatchResult>} [$$source = {}] - The source object to create the BatchResult.
+     */
+    constructor($$source = {}) {
+        if (!("input_path" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["input_path"] = "";
+        }
+        if (!("output_path" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["output_path"] = "";
+        }
+        if (!("serial" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["serial"] = "";
+        }
+        if (!("number" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["number"] = 0;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {string | undefined}
+             */
+            this["error"] = undefined;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new BatchResult instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {BatchResult}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new BatchResult(/** @type {Partial<BatchResult>} */($$parsedSource));
+    }
+}
@@ -6,6 +6,10 @@
 // @ts-ignore: Unused imports
 import { Create as $Create } from "@wailsio/runtime";
 
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as stamp$0 from "../stamp/models.js";
+
 /**
  * AppConfig holds persistent application settings
  */
@@ -22,6 +26,86 @@ export class AppConfig {
              */
             this["prefix"] = "";
         }
+        if (!("series" in $$source)) {
+            /**
+             * Counter series; empty uses the prefix
+             * @member
+             * @type {string}
+             */
+            this["series"] = "";
+        }
+        if (!("serial_format" in $$source)) {
+            /**
+             * e.g. "{prefix}-{yyyy}-{n:5}"
+             * @member
+             * @type {string}
+             */
+            this["serial_format"] = "";
+        }
+        if (!("check_digits" in $$source)) {
+            /**
+             * none, luhn, mod97-10, damm
+             * @member
+             * @type {string}
+             */
+            this["check_digits"] = "";
+        }
+        if (!("reset_period" in $$source)) {
+            /**
+             * never, yearly, monthly
+             * @member
+             * @type {string}
+             */
+            this["reset_period"] = "";
+        }
+        if (!("fiscal_year_start" in $$source)) {
+            /**
+             * Month a yearly period starts (1-12)
+             * @member
+             * @type {number}
+             */
+            this["fiscal_year_start"] = 0;
+        }
+        if (!("counter_backend" in $$source)) {
+            /**
+             * file, sqlite, share or server
+             * @member
+             * @type {string}
+             */
+            this["counter_backend"] = "";
+        }
+        if (!("counter_location" in $$source)) {
+            /**
+             * Directory or database file; empty uses the config dir
+             * @member
+             * @type {string}
+             */
+            this["counter_location"] = "";
+        }
+        if (!("counter_server" in $$source)) {
+            /**
+             * URL of the shared counter server
+             * @member
+             * @type {string}
+             */
+            this["counter_server"] = "";
+        }
+        if (!("counter_token" in $$source)) {
+            /**
+             * Bearer token for the counter server
+             * @member
+             * @type {string}
+             */
+            this["counter_token"] = "";
+        }
+        if (!("admin_pin_hash" in $$source)) {
+            /**
+             * See HashPIN; required for counter overrides
+             * @member
+             * @type {string}
+             */
+            this["admin_pin_hash"] = "";
+        }
         if (!("overlay" in $$source)) {
             /**
              * @member
@@ -31,15 +115,39 @@ export class AppConfig {
         }
         if (!("overlay_color" in $$source)) {
             /**
-             * Hex or Name
+             * Hex or Name; used when OverlayStyle has no color
              * @member
              * @type {string}
              */
             this["overlay_color"] = "";
         }
+        if (!("overlay_template" in $$source)) {
+            /**
+             * e.g. "{serial} · frozen {date} · p. {page}/{pages}"
+             * @member
+             * @type {string}
+             */
+            this["overlay_template"] = "";
+        }
+        if (!("overlay_pages" in $$source)) {
+            /**
+             * first, last, all, odd, even or ranges like "1-3,7"
+             * @member
+             * @type {string}
+             */
+            this["overlay_pages"] = "";
+        }
+        if (!("operator" in $$source)) {
+            /**
+             * Name for {operator}; empty uses the OS user
+             * @member
+             * @type {string}
+             */
+            this["operator"] = "";
+        }
         if (!("overlay_position" in $$source)) {
             /**
-             * top-right, top-left, bottom-right, bottom-left
+             * Anchor, e.g. top-left, center, bottom-right
              * @member
              * @type {string}
              */
@@ -53,6 +161,22 @@ export class AppConfig {
              */
             this["file_suffix"] = "";
         }
+        if (!("test_marker" in $$source)) {
+            /**
+             * Stamped with the serial in test mode
+             * @member
+             * @type {string}
+             */
+            this["test_marker"] = "";
+        }
+        if (!("test_suffix" in $$source)) {
+            /**
+             * Output file suffix in test mode
+             * @member
+             * @type {string}
+             */
+            this["test_suffix"] = "";
+        }
         if (!("overwrite_mode" in $$source)) {
             /**
              * If true, overwrite original file
@@ -69,6 +193,126 @@ export class AppConfig {
              */
             this["compression_level"] = "";
         }
+        if (!("overlay_style" in $$source)) {
+            /**
+             * OverlayStyle is how the overlay text is drawn
+             * @member
+             * @type {stamp$0.Style}
+             */
+            this["overlay_style"] = (new stamp$0.Style());
+        }
+        if (!("overlay_offset_x" in $$source)) {
+            /**
+             * Overlay offsets from the anchored page edges, see stamp.Placement
+             * @member
+             * @type {number}
+             */
+            this["overlay_offset_x"] = 0;
+        }
+        if (!("overlay_offset_y" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["overlay_offset_y"] = 0;
+        }
+        if (!("overlay_offset_unit" in $$source)) {
+            /**
+             * pt or mm
+             * @member
+             * @type {string}
+             */
+            this["overlay_offset_unit"] = "";
+        }
+        if (!("stamp_sets" in $$source)) {
+            /**
+             * StampSets are named lists of stamp layers. When StampSet names one of
+             * them, its layers replace the single overlay above.
+             * @member
+             * @type {stamp$0.Set[]}
+             */
+            this["stamp_sets"] = [];
+        }
+        if (!("stamp_set" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["stamp_set"] = "";
+        }
+        if (!("watermark" in $$source)) {
+            /**
+             * Watermark goes across the pages when no stamp set is in use; a stamp
+             * set brings its own
+             * @member
+             * @type {stamp$0.Watermark}
+             */
+            this["watermark"] = (new stamp$0.Watermark());
+        }
+        if (!("overlay_image" in $$source)) {
+            /**
+             * OverlayImage is an image stamp, such as a company seal, drawn next to
+             * the overlay text when no stamp set is in use
+             * @member
+             * @type {stamp$0.Layer}
+             */
+            this["overlay_image"] = (new stamp$0.Layer());
+        }
+        if (!("overlay_code" in $$source)) {
+            /**
+             * OverlayCode is a QR code or barcode, e.g. of the serial for the
+             * mailroom scanners, drawn with the overlay text when no stamp set is in
+             * use
+             * @member
+             * @type {stamp$0.Layer}
+             */
+            this["overlay_code"] = (new stamp$0.Layer());
+        }
+        if (!("bates" in $$source)) {
+            /**
+             * Bates numbers every page, with or without a stamp set; see
+             * stamp.Bates
+             * @member
+             * @type {stamp$0.Bates}
+             */
+            this["bates"] = (new stamp$0.Bates());
+        }
+        if (!("font_fallback" in $$source)) {
+            /**
+             * FontFallback lists font files of the font folder tried, in order,
+             * for characters missing in a stamp's font, e.g. a CJK font for client
+             * names the built-in font has no glyphs for
+             * @member
+             * @type {string[]}
+             */
+            this["font_fallback"] = [];
+        }
+        if (!("bands" in $$source)) {
+            /**
+             * Bands extend the pages with header and footer bands, so stamps
+             * anchored to the top or bottom do not cover the content
+             * @member
+             * @type {stamp$0.Bands}
+             */
+            this["bands"] = (new stamp$0.Bands());
+        }
+        if (!("audit_sheet" in $$source)) {
+            /**
+             * AuditSheet adds a cover or trailer page summarizing the freeze
+             * @member
+             * @type {stamp$0.AuditSheet}
+             */
+            this["audit_sheet"] = (new stamp$0.AuditSheet());
+        }
+        if (!("letterhead" in $$source)) {
+            /**
+             * Letterhead draws a page of a template PDF in the stamp image folder
+             * under or over the pages
+             * @member
+             * @type {stamp$0.Letterhead}
+             */
+            this["letterhead"] = (new stamp$0.Letterhead());
+        }
 
         Object.assign(this, $$source);
     }
@@ -79,7 +323,59 @@ export class AppConfig {
      * @returns {AppConfig}
      */
     static createFrom($$source = {}) {
+        const $$createField22_0 = $$createType0;
+        const $$createField26_0 = $$createType2;
+        const $$createField28_0 = $$createType3;
+        const $$createField29_0 = $$createType4;
+        const $$createField30_0 = $$createType4;
+        const $$createField31_0 = $$createType5;
+        const $$createField32_0 = $$createType6;
+        const $$createField33_0 = $$createType7;
+        const $$createField34_0 = $$createType8;
+        const $$createField35_0 = $$createType9;
         let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("overlay_style" in $$parsedSource) {
+            $$parsedSource["overlay_style"] = $$createField22_0($$parsedSource["overlay_style"]);
+        }
+        if ("stamp_sets" in $$parsedSource) {
+            $$parsedSource["stamp_sets"] = $$createField26_0($$parsedSource["stamp_sets"]);
+        }
+        if ("watermark" in $$parsedSource) {
+            $$parsedSource["watermark"] = $$createField28_0($$parsedSource["watermark"]);
+        }
+        if ("overlay_image" in $$parsedSource) {
+            $$parsedSource["overlay_image"] = $$createField29_0($$parsedSource["overlay_image"]);
+        }
+        if ("overlay_code" in $$parsedSource) {
+            $$parsedSource["overlay_code"] = $$createField30_0($$parsedSource["overlay_code"]);
+        }
+        if ("bates" in $$parsedSource) {
+            $$parsedSource["bates"] = $$createField31_0($$parsedSource["bates"]);
+        }
+        if ("font_fallback" in $$parsedSource) {
+            $$parsedSource["font_fallback"] = $$createField32_0($$parsedSource["font_fallback"]);
+        }
+        if ("bands" in $$parsedSource) {
+            $$parsedSource["bands"] = $$createField33_0($$parsedSource["bands"]);
+        }
+        if ("audit_sheet" in $$parsedSource) {
+            $$parsedSource["audit_sheet"] = $$createField34_0($$parsedSource["audit_sheet"]);
+        }
+        if ("letterhead" in $$parsedSource) {
+            $$parsedSource["letterhead"] = $$createField35_0($$parsedSource["letterhead"]);
+        }
         return new AppConfig(/** @type {Partial<AppConfig>} */($$parsedSource));
     }
 }
+
+// Private type creation functions
+const $$createType0 = stamp$0.Style.createFrom;
+const $$createType1 = stamp$0.Set.createFrom;
+const $$createType2 = $Create.Array($$createType1);
+const $$createType3 = stamp$0.Watermark.createFrom;
+const $$createType4 = stamp$0.Layer.createFrom;
+const $$createType5 = stamp$0.Bates.createFrom;
+const $$createType6 = $Create.Array($Create.Any);
+const $$createType7 = stamp$0.Bands.createFrom;
+const $$createType8 = stamp$0.AuditSheet.createFrom;
+const $$createType9 = stamp$0.Letterhead.createFrom;
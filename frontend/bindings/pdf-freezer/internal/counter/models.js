@@ -0,0 +1,125 @@
+// @ts-check
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Create as $Create } from "@wailsio/runtime";
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as time$0 from "../../../time/models.js";
+
+/**
+ * Event is one entry in the append-only counter ledger (ledger.jsonl)
+ */
+export class Event {
+    /**
+     * Creates a new Event instance.
+     * @param {Partial<Event>} [$$source = {}] - The source object to create the Event.
+     */
+    constructor($$source = {}) {
+        if (!("time" in $$source)) {
+            /**
+             * @member
+             * @type {time$0.Time}
+             */
+            this["time"] = null;
+        }
+        if (!("series" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["series"] = "";
+        }
+        if (!("kind" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["kind"] = "";
+        }
+        if (!("value" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["value"] = 0;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {number | undefined}
+             */
+            this["previous"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * New period of a reset
+             * @member
+             * @type {string | undefined}
+             */
+            this["period"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Range of a reserve, release or void
+             * @member
+             * @type {number | undefined}
+             */
+            this["first"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {number | undefined}
+             */
+            this["last"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * @member
+             * @type {string | undefined}
+             */
+            this["reason"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Override rewound below issued numbers
+             * @member
+             * @type {boolean | undefined}
+             */
+            this["forced"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Document is the serial of the document a range was issued for
+             * @member
+             * @type {string | undefined}
+             */
+            this["document"] = undefined;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * Revision is the state revision the update that recorded the event
+             * writes; see FileStore.Update
+             * @member
+             * @type {number | undefined}
+             */
+            this["revision"] = undefined;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Event instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Event}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Event(/** @type {Partial<Event>} */($$parsedSource));
+    }
+}
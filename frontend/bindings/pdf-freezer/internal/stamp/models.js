@@ -0,0 +1,812 @@
+// @ts-check
+// Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
+// This file is automatically generated. DO NOT EDIT
+
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import { Create as $Create } from "@wailsio/runtime";
+
+/**
+ * AuditRow is one line of an audit sheet: a label and a template rendered
+ * with the document's fields
+ */
+export class AuditRow {
+    /**
+     * Creates a new AuditRow instance.
+     * @param {Partial<AuditRow>} [$$source = {}] - The source object to create the AuditRow.
+     */
+    constructor($$source = {}) {
+        if (!("label" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["label"] = "";
+        }
+        if (!("value" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["value"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new AuditRow instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {AuditRow}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new AuditRow(/** @type {Partial<AuditRow>} */($$parsedSource));
+    }
+}
+
+/**
+ * AuditSheet is a generated page summarizing the freeze, added to the
+ * output as a cover or trailer page. Its templates are rendered like a
+ * stamp's, with {pages} the page count of the source document.
+ */
+export class AuditSheet {
+    /**
+     * Creates a new AuditSheet instance.
+     * @param {Partial<AuditSheet>} [$$source = {}] - The source object to create the AuditSheet.
+     */
+    constructor($$source = {}) {
+        if (!("position" in $$source)) {
+            /**
+             * cover or trailer; empty for none
+             * @member
+             * @type {string}
+             */
+            this["position"] = "";
+        }
+        if (!("title" in $$source)) {
+            /**
+             * Template; default DefaultAuditTitle
+             * @member
+             * @type {string}
+             */
+            this["title"] = "";
+        }
+        if (!("rows" in $$source)) {
+            /**
+             * Default DefaultAuditRows
+             * @member
+             * @type {AuditRow[]}
+             */
+            this["rows"] = [];
+        }
+        if (!("style" in $$source)) {
+            /**
+             * Row text; the title is bold and larger
+             * @member
+             * @type {Style}
+             */
+            this["style"] = (new Style());
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new AuditSheet instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {AuditSheet}
+     */
+    static createFrom($$source = {}) {
+        const $$createField2_0 = $$createType1;
+        const $$createField3_0 = $$createType2;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("rows" in $$parsedSource) {
+            $$parsedSource["rows"] = $$createField2_0($$parsedSource["rows"]);
+        }
+        if ("style" in $$parsedSource) {
+            $$parsedSource["style"] = $$createField3_0($$parsedSource["style"]);
+        }
+        return new AuditSheet(/** @type {Partial<AuditSheet>} */($$parsedSource));
+    }
+}
+
+/**
+ * Bands extend each page with a blank header band above and a footer band
+ * below the page image, so stamps can go there instead of over the
+ * content. Stamps anchored to the top go in the header band and those
+ * anchored to the bottom in the footer band, placed as if the band were
+ * the page; the others stay on the image. Heights are in mm; 0 means no
+ * band.
+ */
+export class Bands {
+    /**
+     * Creates a new Bands instance.
+     * @param {Partial<Bands>} [$$source = {}] - The source object to create the Bands.
+     */
+    constructor($$source = {}) {
+        if (!("header" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["header"] = 0;
+        }
+        if (!("footer" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["footer"] = 0;
+        }
+        if (!("color" in $$source)) {
+            /**
+             * Band fill, "#RRGGBB" or a basic color name; empty for white
+             * @member
+             * @type {string}
+             */
+            this["color"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Bands instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Bands}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Bands(/** @type {Partial<Bands>} */($$parsedSource));
+    }
+}
+
+/**
+ * Bates numbers every page of a document. In document mode the number is
+ * the serial followed by the page, starting at Start in each document. In
+ * global mode pages take consecutive numbers from a counter series of
+ * their own, so numbering runs on across documents and batches.
+ */
+export class Bates {
+    /**
+     * Creates a new Bates instance.
+     * @param {Partial<Bates>} [$$source = {}] - The source object to create the Bates.
+     */
+    constructor($$source = {}) {
+        if (!("mode" in $$source)) {
+            /**
+             * document or global; empty for none
+             * @member
+             * @type {string}
+             */
+            this["mode"] = "";
+        }
+        if (!("prefix" in $$source)) {
+            /**
+             * Global mode: text before the number, also names the counter series
+             * @member
+             * @type {string}
+             */
+            this["prefix"] = "";
+        }
+        if (!("start" in $$source)) {
+            /**
+             * First number; default 1
+             * @member
+             * @type {number}
+             */
+            this["start"] = 0;
+        }
+        if (!("pad" in $$source)) {
+            /**
+             * Digits; default 4 in document and 6 in global mode
+             * @member
+             * @type {number}
+             */
+            this["pad"] = 0;
+        }
+        if (!("placement" in $$source)) {
+            /**
+             * @member
+             * @type {Placement}
+             */
+            this["placement"] = (new Placement());
+        }
+        if (!("style" in $$source)) {
+            /**
+             * @member
+             * @type {Style}
+             */
+            this["style"] = (new Style());
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Bates instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Bates}
+     */
+    static createFrom($$source = {}) {
+        const $$createField4_0 = $$createType3;
+        const $$createField5_0 = $$createType2;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("placement" in $$parsedSource) {
+            $$parsedSource["placement"] = $$createField4_0($$parsedSource["placement"]);
+        }
+        if ("style" in $$parsedSource) {
+            $$parsedSource["style"] = $$createField5_0($$parsedSource["style"]);
+        }
+        return new Bates(/** @type {Partial<Bates>} */($$parsedSource));
+    }
+}
+
+/**
+ * Code is a machine-readable stamp. A layer with a code type draws a QR
+ * code or Code 128 barcode encoding its rendered template, e.g. "{serial}",
+ * "{serial} {hash8}" or "https://docs.example.com/verify/{serial}".
+ * {hash8} and {hash} are the SHA-256 of the input file: the output cannot
+ * carry its own hash. Code 128 encodes ASCII only.
+ */
+export class Code {
+    /**
+     * Creates a new Code instance.
+     * @param {Partial<Code>} [$$source = {}] - The source object to create the Code.
+     */
+    constructor($$source = {}) {
+        if (!("type" in $$source)) {
+            /**
+             * qr or code128; empty for none
+             * @member
+             * @type {string}
+             */
+            this["type"] = "";
+        }
+        if (!("size" in $$source)) {
+            /**
+             * mm; QR side or bar height, default 20 or 10
+             * @member
+             * @type {number}
+             */
+            this["size"] = 0;
+        }
+        if (!("module" in $$source)) {
+            /**
+             * Code 128 narrowest bar in mm; default 0.33
+             * @member
+             * @type {number}
+             */
+            this["module"] = 0;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Code instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Code}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Code(/** @type {Partial<Code>} */($$parsedSource));
+    }
+}
+
+/**
+ * Image is a picture stamp such as a company seal. The file is a PNG, which
+ * may have an alpha channel, or a JPEG in the stamp image folder of the
+ * config dir. A layer with an image draws it instead of its text.
+ */
+export class Image {
+    /**
+     * Creates a new Image instance.
+     * @param {Partial<Image>} [$$source = {}] - The source object to create the Image.
+     */
+    constructor($$source = {}) {
+        if (!("file" in $$source)) {
+            /**
+             * File name in the stamp image folder
+             * @member
+             * @type {string}
+             */
+            this["file"] = "";
+        }
+        if (!("width" in $$source)) {
+            /**
+             * mm; 0 follows Height and the aspect ratio
+             * @member
+             * @type {number}
+             */
+            this["width"] = 0;
+        }
+        if (!("height" in $$source)) {
+            /**
+             * mm; 0 follows Width
+             * @member
+             * @type {number}
+             */
+            this["height"] = 0;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Image instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Image}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Image(/** @type {Partial<Image>} */($$parsedSource));
+    }
+}
+
+/**
+ * Layer is one stamp drawn on the page: its text, image or code, the pages
+ * that carry it, where it goes and how it looks. Image stamps use only the
+ * opacity and rotation of the style, codes also its color.
+ */
+export class Layer {
+    /**
+     * Creates a new Layer instance.
+     * @param {Partial<Layer>} [$$source = {}] - The source object to create the Layer.
+     */
+    constructor($$source = {}) {
+        if (!("template" in $$source)) {
+            /**
+             * Empty uses DefaultTemplate
+             * @member
+             * @type {string}
+             */
+            this["template"] = "";
+        }
+        if (!("image" in $$source)) {
+            /**
+             * Drawn instead of the text if set
+             * @member
+             * @type {Image}
+             */
+            this["image"] = (new Image());
+        }
+        if (!("code" in $$source)) {
+            /**
+             * Encodes the text instead of showing it if set
+             * @member
+             * @type {Code}
+             */
+            this["code"] = (new Code());
+        }
+        if (!("pages" in $$source)) {
+            /**
+             * See ParsePages
+             * @member
+             * @type {string}
+             */
+            this["pages"] = "";
+        }
+        if (!("placement" in $$source)) {
+            /**
+             * @member
+             * @type {Placement}
+             */
+            this["placement"] = (new Placement());
+        }
+        if (!("style" in $$source)) {
+            /**
+             * @member
+             * @type {Style}
+             */
+            this["style"] = (new Style());
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Layer instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Layer}
+     */
+    static createFrom($$source = {}) {
+        const $$createField1_0 = $$createType4;
+        const $$createField2_0 = $$createType5;
+        const $$createField4_0 = $$createType3;
+        const $$createField5_0 = $$createType2;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("image" in $$parsedSource) {
+            $$parsedSource["image"] = $$createField1_0($$parsedSource["image"]);
+        }
+        if ("code" in $$parsedSource) {
+            $$parsedSource["code"] = $$createField2_0($$parsedSource["code"]);
+        }
+        if ("placement" in $$parsedSource) {
+            $$parsedSource["placement"] = $$createField4_0($$parsedSource["placement"]);
+        }
+        if ("style" in $$parsedSource) {
+            $$parsedSource["style"] = $$createField5_0($$parsedSource["style"]);
+        }
+        return new Layer(/** @type {Partial<Layer>} */($$parsedSource));
+    }
+}
+
+/**
+ * Letterhead is a page of a template PDF, such as the company letterhead,
+ * drawn as vector graphics with the page image. Under the image, the image
+ * is multiplied onto it, so its white background lets the letterhead
+ * show through while the content stays on top.
+ */
+export class Letterhead {
+    /**
+     * Creates a new Letterhead instance.
+     * @param {Partial<Letterhead>} [$$source = {}] - The source object to create the Letterhead.
+     */
+    constructor($$source = {}) {
+        if (!("file" in $$source)) {
+            /**
+             * PDF in the stamp image folder; empty for none
+             * @member
+             * @type {string}
+             */
+            this["file"] = "";
+        }
+        if (!("page" in $$source)) {
+            /**
+             * Template page; default 1
+             * @member
+             * @type {number}
+             */
+            this["page"] = 0;
+        }
+        if (!("layer" in $$source)) {
+            /**
+             * under or over; default under
+             * @member
+             * @type {string}
+             */
+            this["layer"] = "";
+        }
+        if (!("scale" in $$source)) {
+            /**
+             * fit, stretch or none; default fit
+             * @member
+             * @type {string}
+             */
+            this["scale"] = "";
+        }
+        if (!("pages" in $$source)) {
+            /**
+             * See ParsePages; default all
+             * @member
+             * @type {string}
+             */
+            this["pages"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Letterhead instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Letterhead}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Letterhead(/** @type {Partial<Letterhead>} */($$parsedSource));
+    }
+}
+
+/**
+ * Placement positions a stamp on the page as seen by the reader, so "top"
+ * is the top of a landscape page as displayed.
+ * 
+ * OffsetX is the distance from the left or right page edge the stamp is
+ * anchored to, and OffsetY from the top or bottom edge; positive values move
+ * the stamp inward. For the center column and middle row they shift the
+ * stamp right and down.
+ */
+export class Placement {
+    /**
+     * Creates a new Placement instance.
+     * @param {Partial<Placement>} [$$source = {}] - The source object to create the Placement.
+     */
+    constructor($$source = {}) {
+        if (!("anchor" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["anchor"] = "";
+        }
+        if (!("offset_x" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["offset_x"] = 0;
+        }
+        if (!("offset_y" in $$source)) {
+            /**
+             * @member
+             * @type {number}
+             */
+            this["offset_y"] = 0;
+        }
+        if (!("unit" in $$source)) {
+            /**
+             * pt (default) or mm
+             * @member
+             * @type {string}
+             */
+            this["unit"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Placement instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Placement}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Placement(/** @type {Partial<Placement>} */($$parsedSource));
+    }
+}
+
+/**
+ * Set is a named list of layers, drawn in order so later layers sit on top,
+ * with an optional watermark underneath them
+ */
+export class Set {
+    /**
+     * Creates a new Set instance.
+     * @param {Partial<Set>} [$$source = {}] - The source object to create the Set.
+     */
+    constructor($$source = {}) {
+        if (!("name" in $$source)) {
+            /**
+             * @member
+             * @type {string}
+             */
+            this["name"] = "";
+        }
+        if (!("layers" in $$source)) {
+            /**
+             * @member
+             * @type {Layer[]}
+             */
+            this["layers"] = [];
+        }
+        if (!("watermark" in $$source)) {
+            /**
+             * @member
+             * @type {Watermark}
+             */
+            this["watermark"] = (new Watermark());
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Set instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Set}
+     */
+    static createFrom($$source = {}) {
+        const $$createField1_0 = $$createType7;
+        const $$createField2_0 = $$createType8;
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        if ("layers" in $$parsedSource) {
+            $$parsedSource["layers"] = $$createField1_0($$parsedSource["layers"]);
+        }
+        if ("watermark" in $$parsedSource) {
+            $$parsedSource["watermark"] = $$createField2_0($$parsedSource["watermark"]);
+        }
+        return new Set(/** @type {Partial<Set>} */($$parsedSource));
+    }
+}
+
+/**
+ * Style controls how a stamp is drawn. Zero values mean the default, so a
+ * partial style from the config still renders.
+ */
+export class Style {
+    /**
+     * Creates a new Style instance.
+     * @param {Partial<Style>} [$$source = {}] - The source object to create the Style.
+     */
+    constructor($$source = {}) {
+        if (!("color" in $$source)) {
+            /**
+             * Text color, "#RRGGBB" or a basic color name
+             * @member
+             * @type {string}
+             */
+            this["color"] = "";
+        }
+        if (!("size" in $$source)) {
+            /**
+             * Font size in points; default 12
+             * @member
+             * @type {number}
+             */
+            this["size"] = 0;
+        }
+        if (!("bold" in $$source)) {
+            /**
+             * Bold or regular weight
+             * @member
+             * @type {boolean}
+             */
+            this["bold"] = false;
+        }
+        if (!("font" in $$source)) {
+            /**
+             * Font file in the font folder; empty for the built-in font
+             * @member
+             * @type {string}
+             */
+            this["font"] = "";
+        }
+        if (!("opacity" in $$source)) {
+            /**
+             * 0.05-1; 0 means opaque
+             * @member
+             * @type {number}
+             */
+            this["opacity"] = 0;
+        }
+        if (!("rotation" in $$source)) {
+            /**
+             * Degrees counter-clockwise around the stamp center
+             * @member
+             * @type {number}
+             */
+            this["rotation"] = 0;
+        }
+        if (!("box_color" in $$source)) {
+            /**
+             * Background fill; empty for none
+             * @member
+             * @type {string}
+             */
+            this["box_color"] = "";
+        }
+        if (!("border_color" in $$source)) {
+            /**
+             * Box border; empty for none
+             * @member
+             * @type {string}
+             */
+            this["border_color"] = "";
+        }
+        if (!("border_width" in $$source)) {
+            /**
+             * Border width in points; default 0.75
+             * @member
+             * @type {number}
+             */
+            this["border_width"] = 0;
+        }
+        if (!("padding" in $$source)) {
+            /**
+             * Space between text and box edge, points; default 3
+             * @member
+             * @type {number}
+             */
+            this["padding"] = 0;
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Style instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Style}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Style(/** @type {Partial<Style>} */($$parsedSource));
+    }
+}
+
+/**
+ * Watermark is large, faint text across the page, such as "CONFIDENTIAL"
+ * or "COPY". Its font size follows the page size. Zero values mean the
+ * default; an empty Text means no watermark.
+ */
+export class Watermark {
+    /**
+     * Creates a new Watermark instance.
+     * @param {Partial<Watermark>} [$$source = {}] - The source object to create the Watermark.
+     */
+    constructor($$source = {}) {
+        if (!("text" in $$source)) {
+            /**
+             * Template, e.g. "COPY" or "COPY {serial}"
+             * @member
+             * @type {string}
+             */
+            this["text"] = "";
+        }
+        if (!("angle" in $$source)) {
+            /**
+             * Degrees counter-clockwise; 0 is horizontal
+             * @member
+             * @type {number}
+             */
+            this["angle"] = 0;
+        }
+        if (!("scale" in $$source)) {
+            /**
+             * Share of the page the text spans, 0.1-1; default 0.8
+             * @member
+             * @type {number}
+             */
+            this["scale"] = 0;
+        }
+        if (!("opacity" in $$source)) {
+            /**
+             * 0.05-1; default 0.15
+             * @member
+             * @type {number}
+             */
+            this["opacity"] = 0;
+        }
+        if (!("color" in $$source)) {
+            /**
+             * "#RRGGBB" or a basic color name; default gray
+             * @member
+             * @type {string}
+             */
+            this["color"] = "";
+        }
+        if (!("font" in $$source)) {
+            /**
+             * Font file in the font folder; empty for the built-in bold font
+             * @member
+             * @type {string}
+             */
+            this["font"] = "";
+        }
+        if (!("pages" in $$source)) {
+            /**
+             * See ParsePages; default all
+             * @member
+             * @type {string}
+             */
+            this["pages"] = "";
+        }
+
+        Object.assign(this, $$source);
+    }
+
+    /**
+     * Creates a new Watermark instance from a string or object.
+     * @param {any} [$$source = {}]
+     * @returns {Watermark}
+     */
+    static createFrom($$source = {}) {
+        let $$parsedSource = typeof $$source === 'string' ? JSON.parse($$source) : $$source;
+        return new Watermark(/** @type {Partial<Watermark>} */($$parsedSource));
+    }
+}
+
+// Private type creation functions
+const $$createType0 = AuditRow.createFrom;
+const $$createType1 = $Create.Array($$createType0);
+const $$createType2 = Style.createFrom;
+const $$createType3 = Placement.createFrom;
+const $$createType4 = Image.createFrom;
+const $$createType5 = Code.createFrom;
+const $$createType6 = Layer.createFrom;
+const $$createType7 = $Create.Array($$createType6);
+const $$createType8 = Watermark.createFrom;
@@ -14,6 +14,35 @@ import { Call as $Call, CancellablePromise as $CancellablePromise, Create as $Cr
 // eslint-disable-next-line @typescript-eslint/ban-ts-comment
 // @ts-ignore: Unused imports
 import * as config$0 from "../../internal/config/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as counter$0 from "../../internal/counter/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as engine$0 from "../../internal/engine/models.js";
+// eslint-disable-next-line @typescript-eslint/ban-ts-comment
+// @ts-ignore: Unused imports
+import * as stamp$0 from "../../internal/stamp/models.js";
+
+/**
+ * AcknowledgeIntegrityAlert accepts a counter state that failed its
+ * integrity check so numbers can be issued again. It requires the admin PIN
+ * and a reason, which is recorded in the ledger.
+ * @param {string} reason
+ * @param {string} pin
+ * @returns {$CancellablePromise<void>}
+ */
+export function AcknowledgeIntegrityAlert(reason, pin) {
+    return $Call.ByID(2167259254, reason, pin);
+}
+
+/**
+ * CancelBatch stops the running batch after the current file
+ * @returns {$CancellablePromise<void>}
+ */
+export function CancelBatch() {
+    return $Call.ByID(3803196895);
+}
 
 /**
  * CheckDeps checks if system dependencies (GS) are met
@@ -24,7 +53,17 @@ export function CheckDeps() {
 }
 
 /**
- * GetConfig returns current config
+ * DeleteStampSet removes a saved stamp set
+ * @param {string} name
+ * @returns {$CancellablePromise<void>}
+ */
+export function DeleteStampSet(name) {
+    return $Call.ByID(2149043369, name);
+}
+
+/**
+ * GetConfig returns current config. The admin PIN hash and the counter
+ * server token are left out; the frontend never needs them.
  * @returns {$CancellablePromise<config$0.AppConfig>}
  */
 export function GetConfig() {
@@ -34,13 +73,112 @@ export function GetConfig() {
 }
 
 /**
- * GetCurrentNumber returns the next number
+ * GetCounterHistory returns the ledger events of a counter series
+ * @param {string} series
+ * @returns {$CancellablePromise<counter$0.Event[]>}
+ */
+export function GetCounterHistory(series) {
+    return $Call.ByID(2835604073, series).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType2($result);
+    }));
+}
+
+/**
+ * GetCurrentNumber returns the next number of the active series
  * @returns {$CancellablePromise<number>}
  */
 export function GetCurrentNumber() {
     return $Call.ByID(2835224579);
 }
 
+/**
+ * GetCurrentSerial previews the serial the next job will receive, rendered
+ * with the configured format from the number GetCurrentNumber returns
+ * @returns {$CancellablePromise<string>}
+ */
+export function GetCurrentSerial() {
+    return $Call.ByID(3475312986);
+}
+
+/**
+ * GetFonts returns the file names of the imported fonts
+ * @returns {$CancellablePromise<string[]>}
+ */
+export function GetFonts() {
+    return $Call.ByID(1386929333).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType3($result);
+    }));
+}
+
+/**
+ * GetStampSets returns the saved stamp sets
+ * @returns {$CancellablePromise<stamp$0.Set[]>}
+ */
+export function GetStampSets() {
+    return $Call.ByID(2452857673).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType5($result);
+    }));
+}
+
+/**
+ * HasAdminPIN reports whether an admin PIN has been configured
+ * @returns {$CancellablePromise<boolean>}
+ */
+export function HasAdminPIN() {
+    return $Call.ByID(2032706891);
+}
+
+/**
+ * ImportFont copies a TrueType font into the font folder of the config dir
+ * and returns the file name to reference from a stamp style or the
+ * fallback list. OpenType fonts with CFF outlines are refused, as they
+ * cannot be embedded.
+ * @param {string} path
+ * @returns {$CancellablePromise<string>}
+ */
+export function ImportFont(path) {
+    return $Call.ByID(4054543023, path);
+}
+
+/**
+ * ImportLetterhead copies a template PDF into the stamp image folder of
+ * the config dir and returns the file name to reference from the
+ * letterhead
+ * @param {string} path
+ * @returns {$CancellablePromise<string>}
+ */
+export function ImportLetterhead(path) {
+    return $Call.ByID(1884319132, path);
+}
+
+/**
+ * ImportStampImage copies a PNG or JPEG into the stamp image folder of the
+ * config dir and returns the file name to reference from an image stamp
+ * @param {string} path
+ * @returns {$CancellablePromise<string>}
+ */
+export function ImportStampImage(path) {
+    return $Call.ByID(3111551122, path);
+}
+
+/**
+ * IsTestMode reports whether test mode is on
+ * @returns {$CancellablePromise<boolean>}
+ */
+export function IsTestMode() {
+    return $Call.ByID(4081769968);
+}
+
+/**
+ * ListSeries returns the current value of every counter series
+ * @returns {$CancellablePromise<{ [_: string]: number }>}
+ */
+export function ListSeries() {
+    return $Call.ByID(3681541726).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType6($result);
+    }));
+}
+
 /**
  * OnFileDrop handles the file drop event
  * @param {string[]} paths
@@ -65,6 +203,36 @@ export function ProcessFile(inputPath, overlayOverride, prefixOverride, position
     return $Call.ByID(966051994, inputPath, overlayOverride, prefixOverride, positionOverride, suffixOverride, overwriteMode, compressionLevel);
 }
 
+/**
+ * ProcessFiles freezes several PDFs as one batch. A contiguous block of
+ * serials is reserved up front and assigned in file path order; numbers not
+ * used because the batch was cancelled are returned to the counter.
+ * @param {string[]} inputPaths
+ * @param {boolean} overlayOverride
+ * @param {string} prefixOverride
+ * @param {string} positionOverride
+ * @param {string} suffixOverride
+ * @param {boolean} overwriteMode
+ * @param {string} compressionLevel
+ * @returns {$CancellablePromise<engine$0.BatchResult[]>}
+ */
+export function ProcessFiles(inputPaths, overlayOverride, prefixOverride, positionOverride, suffixOverride, overwriteMode, compressionLevel) {
+    return $Call.ByID(2386052811, inputPaths, overlayOverride, prefixOverride, positionOverride, suffixOverride, overwriteMode, compressionLevel).then(/** @type {($result: any) => any} */(($result) => {
+        return $$createType8($result);
+    }));
+}
+
+/**
+ * SaveStampSet stores a named list of stamp layers, each with its own text
+ * template, page scope, placement and style, replacing a set of the same
+ * name
+ * @param {stamp$0.Set} $set
+ * @returns {$CancellablePromise<void>}
+ */
+export function SaveStampSet($set) {
+    return $Call.ByID(2672146903, $set);
+}
+
 /**
  * SelectFile opens a dialog to select a PDF
  * @returns {$CancellablePromise<string>}
@@ -73,6 +241,86 @@ export function SelectFile() {
     return $Call.ByID(751046721);
 }
 
+/**
+ * SelectFont opens a file dialog and imports the chosen font, see
+ * ImportFont
+ * @returns {$CancellablePromise<string>}
+ */
+export function SelectFont() {
+    return $Call.ByID(3880404662);
+}
+
+/**
+ * SelectLetterhead lets the user pick a template PDF and imports it, see
+ * ImportLetterhead; it returns the file name, or "" if cancelled
+ * @returns {$CancellablePromise<string>}
+ */
+export function SelectLetterhead() {
+    return $Call.ByID(44264817);
+}
+
+/**
+ * SelectStampImage lets the user pick a PNG or JPEG and imports it as an
+ * image stamp; it returns the file name to reference, or "" if cancelled
+ * @returns {$CancellablePromise<string>}
+ */
+export function SelectStampImage() {
+    return $Call.ByID(1050411635);
+}
+
+/**
+ * SetAdminPIN sets or changes the admin PIN that protects counter overrides
+ * @param {string} currentPIN
+ * @param {string} newPIN
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetAdminPIN(currentPIN, newPIN) {
+    return $Call.ByID(2458106947, currentPIN, newPIN);
+}
+
+/**
+ * SetAuditSheet sets the page summarizing the freeze that is added to
+ * each output: cover or trailer position, title, rows and style. An empty
+ * position turns it off.
+ * @param {stamp$0.AuditSheet} sheet
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetAuditSheet(sheet) {
+    return $Call.ByID(247402795, sheet);
+}
+
+/**
+ * SetBands sets the header and footer bands, in mm, added above and below
+ * every page so stamps anchored to the top or bottom sit there instead of
+ * over the content. Zero heights turn them off.
+ * @param {stamp$0.Bands} b
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetBands(b) {
+    return $Call.ByID(823026607, b);
+}
+
+/**
+ * SetBates sets the Bates numbering of every page: document or global
+ * mode, prefix, start, padding, position and style. An empty mode turns it
+ * off.
+ * @param {stamp$0.Bates} b
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetBates(b) {
+    return $Call.ByID(2354584524, b);
+}
+
+/**
+ * SetCheckDigits selects the check digit scheme appended to serials
+ * (none, luhn, mod97-10, damm)
+ * @param {string} scheme
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetCheckDigits(scheme) {
+    return $Call.ByID(3796812713, scheme);
+}
+
 /**
  * SetCompressionLevel updates the compression level setting
  * @param {string} level
@@ -83,12 +331,116 @@ export function SetCompressionLevel(level) {
 }
 
 /**
- * SetNumberOverride sets the counter config
+ * SetCounterServer switches to a shared counter server, or back to the local
+ * counter file when url is empty
+ * @param {string} url
+ * @param {string} token
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetCounterServer(url, token) {
+    return $Call.ByID(2048452832, url, token);
+}
+
+/**
+ * SetCounterStore selects the counter storage backend (file, sqlite, share,
+ * server) and its location, and switches to it. The series of the current
+ * store are carried over first, so a new, empty store does not issue the
+ * same numbers again; if that fails, the current store stays in use.
+ * @param {string} backend
+ * @param {string} location
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetCounterStore(backend, location) {
+    return $Call.ByID(1466897052, backend, location);
+}
+
+/**
+ * SetFontFallback sets the imported fonts tried, in order, for characters
+ * missing in a stamp's font
+ * @param {string[]} fonts
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetFontFallback(fonts) {
+    return $Call.ByID(3458507062, fonts);
+}
+
+/**
+ * SetLetterhead sets the template PDF page drawn under or over the pages
+ * of each output and how it is scaled. An empty file turns it off.
+ * @param {stamp$0.Letterhead} l
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetLetterhead(l) {
+    return $Call.ByID(205213323, l);
+}
+
+/**
+ * SetNumberOverride sets the next number of the active series.
+ * It requires the admin PIN and a reason, both recorded with the change.
+ * Going back below numbers already issued is refused unless force is set.
  * @param {number} val
+ * @param {string} reason
+ * @param {string} pin
+ * @param {boolean} force
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetNumberOverride(val, reason, pin, force) {
+    return $Call.ByID(2356315504, val, reason, pin, force);
+}
+
+/**
+ * SetOperator sets the operator name stamped by {operator}; empty uses the
+ * OS user
+ * @param {string} name
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetOperator(name) {
+    return $Call.ByID(3793961789, name);
+}
+
+/**
+ * SetOverlayCode sets the QR code or Code 128 barcode drawn with the
+ * overlay text when no stamp set is in use. It encodes the rendered
+ * template, e.g. "{serial}" or a verification URL such as
+ * "https://docs.example.com/verify/{serial}". An empty type turns it off.
+ * @param {stamp$0.Layer} layer
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetOverlayCode(layer) {
+    return $Call.ByID(4064477658, layer);
+}
+
+/**
+ * SetOverlayImage sets the image stamp drawn next to the overlay text when
+ * no stamp set is in use: file (see ImportStampImage), size in mm, page
+ * scope, placement, opacity and rotation. An empty file turns it off.
+ * @param {stamp$0.Layer} layer
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetOverlayImage(layer) {
+    return $Call.ByID(989300056, layer);
+}
+
+/**
+ * SetOverlayOffset sets the overlay distance from the anchored page edges
+ * in pt or mm
+ * @param {number} x
+ * @param {number} y
+ * @param {string} unit
  * @returns {$CancellablePromise<void>}
  */
-export function SetNumberOverride(val) {
-    return $Call.ByID(2356315504, val);
+export function SetOverlayOffset(x, y, unit) {
+    return $Call.ByID(1241039760, x, y, unit);
+}
+
+/**
+ * SetOverlayPages selects the pages that carry the overlay: first, last,
+ * all, odd, even or page ranges such as "1-3,7,10-"
+ * @param {string} spec
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetOverlayPages(spec) {
+    return $Call.ByID(1654286193, spec);
 }
 
 /**
@@ -100,6 +452,25 @@ export function SetOverlayPosition(pos) {
     return $Call.ByID(3859877424, pos);
 }
 
+/**
+ * SetOverlayStyle updates the overlay color, size, weight, opacity,
+ * rotation and background box
+ * @param {stamp$0.Style} style
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetOverlayStyle(style) {
+    return $Call.ByID(4157968404, style);
+}
+
+/**
+ * SetOverlayTemplate validates and updates the overlay text template
+ * @param {string} tmpl
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetOverlayTemplate(tmpl) {
+    return $Call.ByID(3115151409, tmpl);
+}
+
 /**
  * SetPrefix updates the serial number prefix
  * @param {string} prefix
@@ -109,5 +480,94 @@ export function SetPrefix(prefix) {
     return $Call.ByID(124361737, prefix);
 }
 
+/**
+ * SetResetPolicy updates the automatic counter reset (never, yearly,
+ * monthly) and the month a yearly period starts in
+ * @param {string} period
+ * @param {number} fiscalYearStart
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetResetPolicy(period, fiscalYearStart) {
+    return $Call.ByID(2845165322, period, fiscalYearStart);
+}
+
+/**
+ * SetSerialFormat validates and updates the serial format template
+ * @param {string} format
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetSerialFormat(format) {
+    return $Call.ByID(1139020598, format);
+}
+
+/**
+ * SetSeries selects the counter series; empty uses the prefix
+ * @param {string} series
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetSeries(series) {
+    return $Call.ByID(1084147952, series);
+}
+
+/**
+ * SetTestMode switches test mode on or off. In test mode numbers come from
+ * a sandbox counter, the output is stamped with the test marker and saved
+ * with the test suffix; the production counter and ledger are not touched.
+ * @param {boolean} enabled
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetTestMode(enabled) {
+    return $Call.ByID(2223767634, enabled);
+}
+
+/**
+ * SetTestOptions updates the test mode marker and output suffix
+ * @param {string} marker
+ * @param {string} suffix
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetTestOptions(marker, suffix) {
+    return $Call.ByID(563791845, marker, suffix);
+}
+
+/**
+ * SetWatermark sets the watermark drawn across the pages when no stamp set
+ * is in use: text, angle, size as a share of the page, opacity, color and
+ * pages. Empty text turns it off.
+ * @param {stamp$0.Watermark} wm
+ * @returns {$CancellablePromise<void>}
+ */
+export function SetWatermark(wm) {
+    return $Call.ByID(3118412177, wm);
+}
+
+/**
+ * UseStampSet selects the stamp set applied to new documents; empty uses
+ * the single overlay settings
+ * @param {string} name
+ * @returns {$CancellablePromise<void>}
+ */
+export function UseStampSet(name) {
+    return $Call.ByID(3630336115, name);
+}
+
+/**
+ * ValidateSerial checks the check digits of a typed serial with the
+ * configured scheme
+ * @param {string} s
+ * @returns {$CancellablePromise<boolean>}
+ */
+export function ValidateSerial(s) {
+    return $Call.ByID(2494891125, s);
+}
+
 // Private type creation functions
 const $$createType0 = config$0.AppConfig.createFrom;
+const $$createType1 = counter$0.Event.createFrom;
+const $$createType2 = $Create.Array($$createType1);
+const $$createType3 = $Create.Array($Create.Any);
+const $$createType4 = stamp$0.Set.createFrom;
+const $$createType5 = $Create.Array($$createType4);
+const $$createType6 = $Create.Map($Create.Any, $Create.Any);
+const $$createType7 = engine$0.BatchResult.createFrom;
+const $$createType8 = $Create.Array($$createType7);
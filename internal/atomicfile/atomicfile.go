@@ -0,0 +1,95 @@
+// Package atomicfile writes files so that a crash or power loss never leaves
+// a truncated or half-written file behind.
+package atomicfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to a file name to form the path of its backup copy
+const BackupSuffix = ".bak"
+
+// BackupPath returns the path of the backup copy kept for path
+func BackupPath(path string) string {
+	return path + BackupSuffix
+}
+
+// WriteFile atomically replaces path with data.
+// The data is written to a temp file in the same directory, fsynced and then
+// renamed over path. If path already exists, its current content is first
+// copied to BackupPath(path) so the last good state can be recovered.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if _, err := os.Stat(path); err == nil {
+		if err := copyFile(path, BackupPath(path), perm); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", filepath.Base(path), err)
+		}
+	}
+	return Replace(path, data, perm)
+}
+
+// Replace atomically replaces path with data without taking a backup.
+// Use it instead of WriteFile when the current content of path is known to be
+// bad and must not overwrite the last good backup.
+func Replace(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// Remove the temp file on any failure below; after a successful rename
+	// this is a no-op.
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// copyFile atomically replaces dst with the content of src
+func copyFile(src, dst string, perm os.FileMode) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	return Replace(dst, data, perm)
+}
+
+// syncDir flushes the directory entry so the rename itself is durable.
+// Not all platforms support syncing directories (Windows does not), so
+// failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
@@ -2,20 +2,88 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+
+	"pdf-freezer/internal/atomicfile"
+	"pdf-freezer/internal/counter"
+	"pdf-freezer/internal/serial"
+	"pdf-freezer/internal/stamp"
 )
 
 // AppConfig holds persistent application settings
 type AppConfig struct {
 	Prefix           string `json:"prefix"`
+	Series           string `json:"series"`            // Counter series; empty uses the prefix
+	SerialFormat     string `json:"serial_format"`     // e.g. "{prefix}-{yyyy}-{n:5}"
+	CheckDigits      string `json:"check_digits"`      // none, luhn, mod97-10, damm
+	ResetPeriod      string `json:"reset_period"`      // never, yearly, monthly
+	FiscalYearStart  int    `json:"fiscal_year_start"` // Month a yearly period starts (1-12)
+	CounterBackend   string `json:"counter_backend"`   // file, sqlite, share or server
+	CounterLocation  string `json:"counter_location"`  // Directory or database file; empty uses the config dir
+	CounterServer    string `json:"counter_server"`    // URL of the shared counter server
+	CounterToken     string `json:"counter_token"`     // Bearer token for the counter server
+	AdminPINHash     string `json:"admin_pin_hash"`    // See HashPIN; required for counter overrides
 	Overlay          bool   `json:"overlay"`
-	OverlayColor     string `json:"overlay_color"`     // Hex or Name
-	OverlayPosition  string `json:"overlay_position"`  // top-right, top-left, bottom-right, bottom-left
+	OverlayColor     string `json:"overlay_color"`     // Hex or Name; used when OverlayStyle has no color
+	OverlayTemplate  string `json:"overlay_template"`  // e.g. "{serial} · frozen {date} · p. {page}/{pages}"
+	OverlayPages     string `json:"overlay_pages"`     // first, last, all, odd, even or ranges like "1-3,7"
+	Operator         string `json:"operator"`          // Name for {operator}; empty uses the OS user
+	OverlayPosition  string `json:"overlay_position"`  // Anchor, e.g. top-left, center, bottom-right
 	FileSuffix       string `json:"file_suffix"`       // Suffix for output file, e.g. "_frozen"
+	TestMarker       string `json:"test_marker"`       // Stamped with the serial in test mode
+	TestSuffix       string `json:"test_suffix"`       // Output file suffix in test mode
 	OverwriteMode    bool   `json:"overwrite_mode"`    // If true, overwrite original file
 	CompressionLevel string `json:"compression_level"` // none, low, medium, high
+
+	// OverlayStyle is how the overlay text is drawn
+	OverlayStyle stamp.Style `json:"overlay_style"`
+
+	// Overlay offsets from the anchored page edges, see stamp.Placement
+	OverlayOffsetX    float64 `json:"overlay_offset_x"`
+	OverlayOffsetY    float64 `json:"overlay_offset_y"`
+	OverlayOffsetUnit string  `json:"overlay_offset_unit"` // pt or mm
+
+	// StampSets are named lists of stamp layers. When StampSet names one of
+	// them, its layers replace the single overlay above.
+	StampSets []stamp.Set `json:"stamp_sets"`
+	StampSet  string      `json:"stamp_set"`
+
+	// Watermark goes across the pages when no stamp set is in use; a stamp
+	// set brings its own
+	Watermark stamp.Watermark `json:"watermark"`
+
+	// OverlayImage is an image stamp, such as a company seal, drawn next to
+	// the overlay text when no stamp set is in use
+	OverlayImage stamp.Layer `json:"overlay_image"`
+
+	// OverlayCode is a QR code or barcode, e.g. of the serial for the
+	// mailroom scanners, drawn with the overlay text when no stamp set is in
+	// use
+	OverlayCode stamp.Layer `json:"overlay_code"`
+
+	// Bates numbers every page, with or without a stamp set; see
+	// stamp.Bates
+	Bates stamp.Bates `json:"bates"`
+
+	// FontFallback lists font files of the font folder tried, in order,
+	// for characters missing in a stamp's font, e.g. a CJK font for client
+	// names the built-in font has no glyphs for
+	FontFallback []string `json:"font_fallback"`
+
+	// Bands extend the pages with header and footer bands, so stamps
+	// anchored to the top or bottom do not cover the content
+	Bands stamp.Bands `json:"bands"`
+
+	// AuditSheet adds a cover or trailer page summarizing the freeze
+	AuditSheet stamp.AuditSheet `json:"audit_sheet"`
+
+	// Letterhead draws a page of a template PDF in the stamp image folder
+	// under or over the pages
+	Letterhead stamp.Letterhead `json:"letterhead"`
 }
 
 // Manager handles config persistence
@@ -29,12 +97,31 @@ type Manager struct {
 func DefaultConfig() AppConfig {
 	return AppConfig{
 		Prefix:           "AR",
+		SerialFormat:     serial.DefaultFormat,
+		CheckDigits:      serial.CheckNone,
+		ResetPeriod:      counter.ResetNever,
+		FiscalYearStart:  1,
+		CounterBackend:   counter.BackendFile,
 		Overlay:          true,
 		OverlayColor:     "#FF0000",
-		OverlayPosition:  "bottom-right",
+		OverlayStyle:     stamp.Style{Size: stamp.DefaultSize, Bold: true, Opacity: 1},
+		OverlayTemplate:  stamp.DefaultTemplate,
+		OverlayPages:     stamp.PagesFirst,
+		OverlayPosition:  stamp.AnchorBottomRight,
 		FileSuffix:       "_frozen",
+		TestMarker:       "TEST",
+		TestSuffix:       "_test",
 		OverwriteMode:    false,
 		CompressionLevel: "none",
+
+		OverlayOffsetX:    1,
+		OverlayOffsetY:    1,
+		OverlayOffsetUnit: stamp.UnitPt,
+
+		Bates: stamp.Bates{
+			Placement: stamp.Placement{Anchor: stamp.AnchorBottomCenter, OffsetY: 5, Unit: stamp.UnitMM},
+			Style:     stamp.Style{Color: "#000000", Size: 10},
+		},
 	}
 }
 
@@ -60,8 +147,11 @@ func GetCompressionSettings(level string) CompressionSettings {
 
 // ... existing methods ...
 
-// UpdateOverlayPosition updates and saves position
+// UpdateOverlayPosition validates and saves the overlay anchor
 func (m *Manager) UpdateOverlayPosition(pos string) error {
+	if err := (stamp.Placement{Anchor: pos}).Validate(); err != nil {
+		return fmt.Errorf("invalid overlay position: %w", err)
+	}
 	m.mu.Lock()
 	m.Current.OverlayPosition = pos
 	m.mu.Unlock()
@@ -85,24 +175,47 @@ func NewManager() (*Manager, error) {
 	}
 
 	if err := m.Load(); err != nil {
-		// If load fails (e.g. no file), we use defaults which are set.
-		// We might want to save the defaults?
-		_ = m.Save()
+		// Only write defaults for a fresh install. A config that exists but
+		// cannot be read (and has no usable backup) is left untouched so it
+		// can still be inspected; defaults are used in memory.
+		if os.IsNotExist(err) {
+			_ = m.Save()
+		}
 	}
 
 	return m, nil
 }
 
-// Load reads config from disk
+// Load reads config from disk, falling back to the backup copy if the
+// config file is corrupt
 func (m *Manager) Load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := os.ReadFile(m.configPath)
+	cfg, err := readConfig(m.configPath)
+	if err != nil && !os.IsNotExist(err) {
+		if backup, bErr := readConfig(atomicfile.BackupPath(m.configPath)); bErr == nil {
+			cfg, err = backup, nil
+		}
+	}
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, &m.Current)
+	m.Current = cfg
+	return nil
+}
+
+// readConfig parses a config file on top of the defaults
+func readConfig(path string) (AppConfig, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
 }
 
 // Save writes config to disk
@@ -114,7 +227,11 @@ func (m *Manager) Save() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(m.configPath, data, 0644)
+	// Keep the backup pointing at the last config that could be read
+	if _, err := readConfig(m.configPath); err != nil {
+		return atomicfile.Replace(m.configPath, data, 0644)
+	}
+	return atomicfile.WriteFile(m.configPath, data, 0644)
 }
 
 // UpdatePrefix updates and saves prefix
@@ -125,6 +242,469 @@ func (m *Manager) UpdatePrefix(prefix string) error {
 	return m.Save()
 }
 
+// UpdateSeries updates and saves the counter series
+func (m *Manager) UpdateSeries(series string) error {
+	m.mu.Lock()
+	m.Current.Series = series
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateSerialFormat validates and saves the serial format template
+func (m *Manager) UpdateSerialFormat(format string) error {
+	f, err := serial.Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid serial format: %w", err)
+	}
+	m.mu.Lock()
+	if err := checkResetFormat(f, m.Current.ResetPolicy()); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.Current.SerialFormat = format
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// Style returns the overlay style, taking the color from OverlayColor if
+// the style has none
+func (c AppConfig) Style() stamp.Style {
+	s := c.OverlayStyle
+	if s.Color == "" {
+		s.Color = c.OverlayColor
+	}
+	return s
+}
+
+// Placement returns where the overlay is drawn
+func (c AppConfig) Placement() stamp.Placement {
+	return stamp.Placement{
+		Anchor:  c.OverlayPosition,
+		OffsetX: c.OverlayOffsetX,
+		OffsetY: c.OverlayOffsetY,
+		Unit:    c.OverlayOffsetUnit,
+	}
+}
+
+// OverlayLayers returns the single overlay as stamp layers: the text and,
+// if configured, the image stamp and the code
+func (c AppConfig) OverlayLayers() []stamp.Layer {
+	layers := []stamp.Layer{{
+		Template:  c.OverlayTemplate,
+		Pages:     c.OverlayPages,
+		Placement: c.Placement(),
+		Style:     c.Style(),
+	}}
+	if c.OverlayImage.Image.Enabled() {
+		layers = append(layers, c.OverlayImage)
+	}
+	if c.OverlayCode.Code.Enabled() {
+		layers = append(layers, c.OverlayCode)
+	}
+	return layers
+}
+
+// UpdateOverlayImage validates and saves the image stamp; an empty file
+// turns it off
+func (m *Manager) UpdateOverlayImage(l stamp.Layer) error {
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid image stamp: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.OverlayImage = l
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateOverlayCode validates and saves the code stamp; an empty type turns
+// it off
+func (m *Manager) UpdateOverlayCode(l stamp.Layer) error {
+	l.Image = stamp.Image{}
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid code stamp: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.OverlayCode = l
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// ImageDir is the folder that image stamp files are kept in
+func (m *Manager) ImageDir() string {
+	return filepath.Join(filepath.Dir(m.configPath), "stamps")
+}
+
+// FontDir is the folder that font files are kept in
+func (m *Manager) FontDir() string {
+	return filepath.Join(filepath.Dir(m.configPath), "fonts")
+}
+
+// UpdateFontFallback validates and saves the font fallback list
+func (m *Manager) UpdateFontFallback(fonts []string) error {
+	if err := stamp.ValidateFallback(fonts); err != nil {
+		return fmt.Errorf("invalid font fallback: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.FontFallback = fonts
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// ActiveStampSet returns the stamp set in use, if any
+func (c AppConfig) ActiveStampSet() (stamp.Set, bool) {
+	if c.StampSet == "" {
+		return stamp.Set{}, false
+	}
+	for _, set := range c.StampSets {
+		if set.Name == c.StampSet {
+			return set, true
+		}
+	}
+	return stamp.Set{}, false
+}
+
+// SaveStampSet validates and stores a stamp set, replacing the set of the
+// same name
+func (m *Manager) SaveStampSet(set stamp.Set) error {
+	set.Name = strings.TrimSpace(set.Name)
+	if err := set.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	sets := make([]stamp.Set, 0, len(m.Current.StampSets)+1)
+	replaced := false
+	for _, s := range m.Current.StampSets {
+		if s.Name == set.Name {
+			s, replaced = set, true
+		}
+		sets = append(sets, s)
+	}
+	if !replaced {
+		sets = append(sets, set)
+	}
+	m.Current.StampSets = sets
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// DeleteStampSet removes a stamp set; if it was in use, the single overlay
+// applies again
+func (m *Manager) DeleteStampSet(name string) error {
+	m.mu.Lock()
+	sets := make([]stamp.Set, 0, len(m.Current.StampSets))
+	for _, s := range m.Current.StampSets {
+		if s.Name != name {
+			sets = append(sets, s)
+		}
+	}
+	if len(sets) == len(m.Current.StampSets) {
+		m.mu.Unlock()
+		return fmt.Errorf("unknown stamp set %q", name)
+	}
+	m.Current.StampSets = sets
+	if m.Current.StampSet == name {
+		m.Current.StampSet = ""
+	}
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UseStampSet selects the stamp set applied to new documents; empty uses
+// the single overlay
+func (m *Manager) UseStampSet(name string) error {
+	m.mu.Lock()
+	if name != "" {
+		found := false
+		for _, s := range m.Current.StampSets {
+			found = found || s.Name == name
+		}
+		if !found {
+			m.mu.Unlock()
+			return fmt.Errorf("unknown stamp set %q", name)
+		}
+	}
+	m.Current.StampSet = name
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateWatermark validates and saves the watermark; empty text turns it
+// off
+func (m *Manager) UpdateWatermark(wm stamp.Watermark) error {
+	wm.Text = strings.TrimSpace(wm.Text)
+	if err := wm.Validate(); err != nil {
+		return fmt.Errorf("invalid watermark: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.Watermark = wm
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateBates validates and saves the Bates numbering; an empty mode turns
+// it off
+func (m *Manager) UpdateBates(b stamp.Bates) error {
+	b.Prefix = strings.TrimSpace(b.Prefix)
+	if err := b.Validate(); err != nil {
+		return fmt.Errorf("invalid Bates numbering: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.Bates = b
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateBands validates and saves the header and footer bands; zero
+// heights turn them off
+func (m *Manager) UpdateBands(b stamp.Bands) error {
+	if err := b.Validate(); err != nil {
+		return fmt.Errorf("invalid bands: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.Bands = b
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateAuditSheet validates and saves the audit sheet; an empty position
+// turns it off
+func (m *Manager) UpdateAuditSheet(a stamp.AuditSheet) error {
+	if err := a.Validate(); err != nil {
+		return fmt.Errorf("invalid audit sheet: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.AuditSheet = a
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateLetterhead validates and saves the letterhead; an empty file
+// turns it off
+func (m *Manager) UpdateLetterhead(l stamp.Letterhead) error {
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid letterhead: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.Letterhead = l
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateOverlayOffset validates and saves the overlay offsets
+func (m *Manager) UpdateOverlayOffset(x, y float64, unit string) error {
+	if err := (stamp.Placement{Unit: unit}).Validate(); err != nil {
+		return fmt.Errorf("invalid overlay offset: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.OverlayOffsetX = x
+	m.Current.OverlayOffsetY = y
+	m.Current.OverlayOffsetUnit = unit
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateOverlayStyle validates and saves the overlay style
+func (m *Manager) UpdateOverlayStyle(s stamp.Style) error {
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("invalid overlay style: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.OverlayStyle = s
+	if s.Color != "" {
+		m.Current.OverlayColor = s.Color
+	}
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateOverlayTemplate validates and saves the overlay text template
+func (m *Manager) UpdateOverlayTemplate(tmpl string) error {
+	if _, err := stamp.Parse(tmpl); err != nil {
+		return fmt.Errorf("invalid overlay template: %w", err)
+	}
+	m.mu.Lock()
+	m.Current.OverlayTemplate = tmpl
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateOverlayPages validates and saves the pages that carry the overlay
+func (m *Manager) UpdateOverlayPages(spec string) error {
+	scope, err := stamp.ParsePages(spec)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.Current.OverlayPages = scope.String()
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateOperator saves the operator name stamped by {operator}
+func (m *Manager) UpdateOperator(name string) error {
+	m.mu.Lock()
+	m.Current.Operator = strings.TrimSpace(name)
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateTestMode saves the test mode marker and output suffix. The suffix
+// must differ from the normal one so test output is never mistaken for a
+// real document.
+func (m *Manager) UpdateTestMode(marker, suffix string) error {
+	marker = strings.TrimSpace(marker)
+	if marker == "" {
+		return fmt.Errorf("test marker must not be empty")
+	}
+	m.mu.Lock()
+	if suffix == "" || suffix == m.Current.FileSuffix {
+		m.mu.Unlock()
+		return fmt.Errorf("test suffix must be set and differ from the file suffix %q", m.Current.FileSuffix)
+	}
+	m.Current.TestMarker = marker
+	m.Current.TestSuffix = suffix
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateCheckDigits validates and saves the check digit scheme
+func (m *Manager) UpdateCheckDigits(scheme string) error {
+	if _, err := serial.ParseCheck(scheme); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.Current.CheckDigits = scheme
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// ResetPolicy returns the counter reset policy from the config
+func (c AppConfig) ResetPolicy() counter.ResetPolicy {
+	return counter.ResetPolicy{Period: c.ResetPeriod, FiscalYearStart: c.FiscalYearStart}
+}
+
+// UpdateResetPolicy validates and saves the counter reset policy
+func (m *Manager) UpdateResetPolicy(period string, fiscalYearStart int) error {
+	policy := counter.ResetPolicy{Period: period, FiscalYearStart: fiscalYearStart}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	format := m.Current.SerialFormat
+	if format == "" {
+		format = serial.DefaultFormat
+	}
+	f, err := serial.Parse(format)
+	if err == nil {
+		err = checkResetFormat(f, policy)
+	}
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.Current.ResetPeriod = period
+	m.Current.FiscalYearStart = fiscalYearStart
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// checkResetFormat refuses a serial format that renders the same serial in
+// two reset periods. A yearly reset needs the year in the serial; a monthly
+// reset or a fiscal year, whose periods span two calendar years, also needs
+// the month.
+func checkResetFormat(f serial.Format, policy counter.ResetPolicy) error {
+	var year, month bool
+	reset := policy.Period
+	switch policy.Period {
+	case counter.ResetYearly:
+		year = true
+		if policy.FiscalYearStart > 1 {
+			month, reset = true, "fiscal year"
+		}
+	case counter.ResetMonthly:
+		year, month = true, true
+	}
+	switch {
+	case year && !f.Uses("yyyy", "yy"):
+		return fmt.Errorf("serial format %q has no year, so the %s reset would issue the same serials again; add {yyyy}", f, reset)
+	case month && !f.Uses("mm"):
+		return fmt.Errorf("serial format %q has no month, so the %s reset would issue the same serials again; add {mm}", f, reset)
+	}
+	return nil
+}
+
+// StoreConfig returns the counter storage settings from the config.
+// A counter server configured without a backend selects the server backend.
+func (c AppConfig) StoreConfig() counter.StoreConfig {
+	backend := c.CounterBackend
+	if backend == "" && c.CounterServer != "" {
+		backend = counter.BackendServer
+	}
+	return counter.StoreConfig{
+		Backend:   backend,
+		Location:  c.CounterLocation,
+		ServerURL: c.CounterServer,
+		Token:     c.CounterToken,
+	}
+}
+
+// UpdateCounterStore updates and saves the counter storage backend
+func (m *Manager) UpdateCounterStore(backend, location string) error {
+	switch backend {
+	case counter.BackendFile, counter.BackendSQLite, counter.BackendShare, counter.BackendServer:
+	default:
+		return fmt.Errorf("unknown counter backend %q", backend)
+	}
+	m.mu.Lock()
+	m.Current.CounterBackend = backend
+	m.Current.CounterLocation = location
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateCounterServer updates and saves the shared counter server settings
+func (m *Manager) UpdateCounterServer(url, token string) error {
+	m.mu.Lock()
+	m.Current.CounterServer = url
+	m.Current.CounterToken = token
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// UpdateAdminPIN replaces the admin PIN. If a PIN is already set, the
+// current one must be given.
+func (m *Manager) UpdateAdminPIN(currentPIN, newPIN string) error {
+	m.mu.RLock()
+	existing := m.Current.AdminPINHash
+	m.mu.RUnlock()
+	if existing != "" && !VerifyPIN(existing, currentPIN) {
+		return fmt.Errorf("current admin PIN is incorrect")
+	}
+
+	hash, err := HashPIN(newPIN)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.Current.AdminPINHash = hash
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// CheckAdminPIN verifies pin against the configured admin PIN
+func (m *Manager) CheckAdminPIN(pin string) error {
+	m.mu.RLock()
+	hash := m.Current.AdminPINHash
+	m.mu.RUnlock()
+	if hash == "" {
+		return fmt.Errorf("no admin PIN configured; set one before changing the counter")
+	}
+	if !VerifyPIN(hash, pin) {
+		return fmt.Errorf("admin PIN is incorrect")
+	}
+	return nil
+}
+
 // UpdateOverlay settings
 func (m *Manager) UpdateOverlay(enabled bool) error {
 	m.mu.Lock()
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"pdf-freezer/internal/counter"
+	"pdf-freezer/internal/serial"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	return &Manager{
+		configPath: filepath.Join(t.TempDir(), "config.json"),
+		Current:    DefaultConfig(),
+	}
+}
+
+func TestResetPolicyNeedsDistinctSerials(t *testing.T) {
+	tests := []struct {
+		format string
+		period string
+		start  int
+		ok     bool
+	}{
+		{serial.DefaultFormat, counter.ResetNever, 1, true},
+		{serial.DefaultFormat, counter.ResetYearly, 1, false},
+		{"{prefix}-{yyyy}-{n:4}", counter.ResetYearly, 1, true},
+		{"{prefix}{yy}{n:4}", counter.ResetYearly, 1, true},
+		{"{prefix}-{yyyy}-{n:4}", counter.ResetYearly, 4, false},
+		{"{prefix}-{yyyy}{mm}-{n:4}", counter.ResetYearly, 4, true},
+		{"{prefix}-{yyyy}-{n:4}", counter.ResetMonthly, 1, false},
+		{"{prefix}-{mm}-{n:4}", counter.ResetMonthly, 1, false},
+		{"{prefix}-{yyyy}{mm}-{n:4}", counter.ResetMonthly, 1, true},
+	}
+	for _, tt := range tests {
+		// Saving the policy under the format
+		m := newTestManager(t)
+		m.Current.SerialFormat = tt.format
+		if err := m.UpdateResetPolicy(tt.period, tt.start); (err == nil) != tt.ok {
+			t.Errorf("UpdateResetPolicy(%s, %d) with %q: err = %v, want ok %v", tt.period, tt.start, tt.format, err, tt.ok)
+		}
+
+		// Saving the format under the policy
+		m = newTestManager(t)
+		m.Current.ResetPeriod, m.Current.FiscalYearStart = tt.period, tt.start
+		if err := m.UpdateSerialFormat(tt.format); (err == nil) != tt.ok {
+			t.Errorf("UpdateSerialFormat(%q) under %s/%d: err = %v, want ok %v", tt.format, tt.period, tt.start, err, tt.ok)
+		}
+	}
+}
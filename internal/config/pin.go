@@ -0,0 +1,62 @@
+package config
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pinIterations is the PBKDF2 work factor for new PIN hashes
+const pinIterations = 600000
+
+// minPINLength is the shortest accepted admin PIN or passphrase
+const minPINLength = 4
+
+// HashPIN derives a salted PBKDF2-SHA256 hash of an admin PIN or passphrase,
+// encoded as "pbkdf2-sha256$<iterations>$<salt>$<hash>"
+func HashPIN(pin string) (string, error) {
+	if len(pin) < minPINLength {
+		return "", fmt.Errorf("admin PIN must be at least %d characters", minPINLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, pin, salt, pinIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", pinIterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// VerifyPIN reports whether pin matches a hash created by HashPIN
+func VerifyPIN(hash, pin string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < 1 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, pin, salt, iter, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
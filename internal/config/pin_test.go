@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPIN(t *testing.T) {
+	tests := []struct {
+		pin string
+		ok  bool
+	}{
+		{"", false},
+		{"123", false},
+		{"1234", true},
+		{"correct horse battery staple", true},
+	}
+	for _, tt := range tests {
+		hash, err := HashPIN(tt.pin)
+		if (err == nil) != tt.ok {
+			t.Errorf("HashPIN(%q): err = %v, want ok %v", tt.pin, err, tt.ok)
+			continue
+		}
+		if tt.ok && (!strings.HasPrefix(hash, "pbkdf2-sha256$") || strings.Contains(hash, tt.pin)) {
+			t.Errorf("HashPIN(%q) = %q", tt.pin, hash)
+		}
+	}
+
+	// Each hash is salted
+	a, _ := HashPIN("1234")
+	b, _ := HashPIN("1234")
+	if a == b {
+		t.Error("Two hashes of the same PIN are equal")
+	}
+}
+
+func TestVerifyPIN(t *testing.T) {
+	hash, err := HashPIN("4711")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	tests := []struct {
+		name string
+		hash string
+		pin  string
+		want bool
+	}{
+		{"correct", hash, "4711", true},
+		{"wrong", hash, "4712", false},
+		{"empty PIN", hash, "", false},
+		{"empty hash", "", "4711", false},
+		{"other scheme", "bcrypt$" + strings.Join(parts[1:], "$"), "4711", false},
+		{"bad iterations", strings.Join([]string{parts[0], "x", parts[2], parts[3]}, "$"), "4711", false},
+		{"zero iterations", strings.Join([]string{parts[0], "0", parts[2], parts[3]}, "$"), "4711", false},
+		{"bad salt", strings.Join([]string{parts[0], parts[1], "!!", parts[3]}, "$"), "4711", false},
+		{"truncated", strings.Join(parts[:3], "$"), "4711", false},
+	}
+	for _, tt := range tests {
+		if got := VerifyPIN(tt.hash, tt.pin); got != tt.want {
+			t.Errorf("%s: VerifyPIN = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
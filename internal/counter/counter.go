@@ -1,25 +1,93 @@
 package counter
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// DefaultSeries is the series used when a job does not name one
+const DefaultSeries = "default"
+
 // CounterState represents the persisted state
 type CounterState struct {
-	Current int `json:"current"`
+	// Legacy is the single global counter from before named series existed.
+	// New serial series start from it, so numbers issued under the old
+	// counter are never issued again under any prefix.
+	Legacy int                    `json:"current,omitempty"`
+	Series map[string]SeriesState `json:"series,omitempty"`
+	// Revision increases with every stored update; FileStore uses it to
+	// recognize the events of an update that crashed (see verify)
+	Revision int64 `json:"revision,omitempty"`
+	// LedgerSize and LastIssued record the end of the ledger when the state
+	// was written, so restoring an older counter.json is detected
+	LedgerSize int64  `json:"ledger_size,omitempty"`
+	LastIssued string `json:"last_issued,omitempty"`
+	// MAC seals the state against editing outside the app
+	MAC string `json:"mac,omitempty"`
+}
+
+// SeriesState is the persisted state of one named sequence
+type SeriesState struct {
+	Current int    `json:"current"`
+	Period  string `json:"period,omitempty"` // Reset period of the last issuance
+}
+
+// auxiliarySeries are the name prefixes of series that count something
+// other than serials, such as global Bates numbers (see stamp.Bates.Series)
+var auxiliarySeries = []string{"bates:"}
+
+// series returns the state of name, seeding unknown serial series from
+// Legacy. Auxiliary series start at 0.
+func (s *CounterState) series(name string) SeriesState {
+	if st, ok := s.Series[name]; ok {
+		return st
+	}
+	for _, prefix := range auxiliarySeries {
+		if strings.HasPrefix(name, prefix) {
+			return SeriesState{}
+		}
+	}
+	return SeriesState{Current: s.Legacy}
+}
+
+// setSeries stores the state of name
+func (s *CounterState) setSeries(name string, st SeriesState) {
+	if s.Series == nil {
+		s.Series = make(map[string]SeriesState)
+	}
+	s.Series[name] = st
+}
+
+// SeriesName normalizes a series name, mapping blank names to DefaultSeries
+func SeriesName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return DefaultSeries
+	}
+	return name
 }
 
-// Manager handles the persistent counter
+// Manager issues numbers from a counter Store.
+// It holds the numbering rules (series, resets, overrides); the store only
+// makes each update atomic and durable.
 type Manager struct {
-	mu         sync.Mutex
-	configPath string
-	lockPath   string
-	statePath  string
-	locked     bool
+	mu    sync.Mutex
+	store Store
+
+	// OnStaleLock, if set, is called when a lock left behind by a crashed
+	// process is detected and taken over
+	OnStaleLock func(LockInfo)
+
+	// OnReset, if set, is called when a series restarts for a new period
+	OnReset func(Event)
+
+	policy ResetPolicy
 }
 
 // NewManager creates a new counter manager
@@ -29,39 +97,61 @@ func NewManager() (*Manager, error) {
 		return nil, fmt.Errorf("failed to get user config dir: %w", err)
 	}
 
-	appDir := filepath.Join(configDir, "pdf-freezer")
-	if err := os.MkdirAll(appDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create config dir: %w", err)
+	return newManagerAt(filepath.Join(configDir, "pdf-freezer"))
+}
+
+// NewSandboxManager creates the counter used in test mode. It keeps its own
+// state and ledger in a separate directory and never touches the production
+// counter.
+func NewSandboxManager() (*Manager, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user config dir: %w", err)
 	}
 
-	return &Manager{
-		configPath: appDir,
-		statePath:  filepath.Join(appDir, "counter.json"),
-		lockPath:   filepath.Join(appDir, "counter.lock"),
-	}, nil
+	return newManagerAt(filepath.Join(configDir, "pdf-freezer", "sandbox"))
 }
 
-// Lock attempts to acquire an exclusive lock for batch processing
-// It creates a .lock file. If it exists, it returns an error.
-func (m *Manager) Lock() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if m.locked {
-		return fmt.Errorf("already locked by this instance")
+// newManagerAt creates a counter manager that keeps its files in appDir
+func newManagerAt(appDir string) (*Manager, error) {
+	store, err := NewFileStore(appDir)
+	if err != nil {
+		return nil, err
 	}
+	return NewManagerWithStore(store), nil
+}
 
-	// Try to create lock file specifically
-	f, err := os.OpenFile(m.lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
-	if err != nil {
-		if os.IsExist(err) {
-			return fmt.Errorf("counter is locked by another instance or process")
+// NewManagerWithStore creates a counter manager on top of store
+func NewManagerWithStore(store Store) *Manager {
+	m := &Manager{store: store}
+	if fs, ok := store.(*FileStore); ok {
+		fs.onStale = func(info LockInfo) {
+			if m.OnStaleLock != nil {
+				m.OnStaleLock(info)
+			}
 		}
-		return err
 	}
-	f.Close()
+	return m
+}
+
+// Close releases the underlying store
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.store.Close()
+}
 
-	m.locked = true
+// Lock holds the store's lock for batch processing until Unlock, so no other
+// process can issue numbers in between. Stores without a long-lived lock
+// (the counter server) make every single update atomic instead, and Lock is
+// a no-op for them.
+func (m *Manager) Lock() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.store.(batchLocker); ok {
+		return l.Lock()
+	}
 	return nil
 }
 
@@ -70,94 +160,314 @@ func (m *Manager) Unlock() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if !m.locked {
-		return nil
+	if l, ok := m.store.(batchLocker); ok {
+		return l.Unlock()
 	}
+	return nil
+}
 
-	if err := os.Remove(m.lockPath); err != nil {
-		return fmt.Errorf("failed to remove lock file: %w", err)
+// ForceUnlock releases a batch lock held by this instance and clears the
+// owner record of a lock left behind by a crashed process.
+// A lock held by a live process is not broken.
+func (m *Manager) ForceUnlock() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.store.(batchLocker); ok {
+		return l.ForceUnlock()
 	}
+	return nil
+}
 
-	m.locked = false
+// SetResetPolicy sets the automatic reset policy applied to all series
+func (m *Manager) SetResetPolicy(p ResetPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.policy = p
+	m.mu.Unlock()
 	return nil
 }
 
-// GetCurrent returns the current counter value without incrementing
-func (m *Manager) GetCurrent() (int, error) {
-	state, err := m.loadState()
+// GetCurrent returns the current value of a series without incrementing.
+// If the series is due for a periodic reset, it returns 0.
+func (m *Manager) GetCurrent(series string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.store.Load()
 	if err != nil {
 		return 0, err
 	}
-	return state.Current, nil
+	st := state.series(SeriesName(series))
+	m.policy.applyReset(&st, time.Now())
+	return st.Current, nil
 }
 
-// GetNext increments the counter and returns the NEW value
-// It automatically persists the change.
-func (m *Manager) GetNext() (int, error) {
+// load reads the state. Reads take m.mu like updates, because loading a
+// FileStore uses the integrity bookkeeping that Update changes.
+func (m *Manager) load() (CounterState, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.store.Load()
+}
 
-	// 1. Load
-	state, err := m.loadState()
+// ListSeries returns the current value of every series that has been used
+func (m *Manager) ListSeries() (map[string]int, error) {
+	state, err := m.load()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	out := make(map[string]int, len(state.Series))
+	for name, st := range state.Series {
+		out[name] = st.Current
 	}
+	return out, nil
+}
 
-	// 2. Increment
-	state.Current++
+// SeriesNames returns the names of all used series, sorted
+func (m *Manager) SeriesNames() ([]string, error) {
+	all, err := m.ListSeries()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
 
-	// 3. Save
-	if err := m.saveState(state); err != nil {
+// GetNext increments a series and returns the NEW value
+// It automatically persists the change and records it in the ledger.
+func (m *Manager) GetNext(series string) (int, error) {
+	next, resetEvt, err := m.next(SeriesName(series))
+	if err != nil {
 		return 0, err
 	}
-
-	return state.Current, nil
+	if resetEvt != nil && m.OnReset != nil {
+		m.OnReset(*resetEvt)
+	}
+	return next, nil
 }
 
-// SetOverride forces the counter to a specific value
-func (m *Manager) SetOverride(val int) error {
+// next issues the next number of series and returns the reset that
+// preceded it, if any
+func (m *Manager) next(series string) (int, *Event, error) {
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.next(series)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	state := CounterState{Current: val}
-	return m.saveState(state)
+	var (
+		next     int
+		resetEvt *Event
+	)
+	err := m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		next, resetEvt = 0, nil
+
+		// Reset for a new period, then increment
+		now := time.Now()
+		st := state.series(series)
+		reset, previous := m.policy.applyReset(&st, now)
+		st.Current++
+		state.setSeries(series, st)
+		next = st.Current
+
+		var events []Event
+		if reset {
+			resetEvt = &Event{Time: now, Series: series, Kind: EventReset, Period: st.Period, Previous: previous}
+			events = append(events, *resetEvt)
+		}
+		return append(events, Event{Time: now, Series: series, Kind: EventIssue, Value: next}), nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return next, resetEvt, nil
+}
+
+// ErrRewind is returned when an override would set a series below numbers
+// that were already issued
+var ErrRewind = errors.New("override would rewind below already issued numbers")
+
+// Override is a manual change of a series' current value
+type Override struct {
+	Value  int    // New current value; the next number issued is Value+1
+	Reason string // Mandatory justification, recorded in the ledger
+	Force  bool   // Allow rewinding below already issued numbers
+	// PIN is the admin PIN, checked by the counter server; local stores
+	// leave the check to the caller
+	PIN string
 }
 
-func (m *Manager) loadState() (CounterState, error) {
-	data, err := os.ReadFile(m.statePath)
-	if os.IsNotExist(err) {
-		// Default start at 0 (first GetNext will be 1)
-		return CounterState{Current: 0}, nil
+// SetOverride forces a series to a specific value.
+// Rewinding below the highest number issued since the series' last reset
+// is refused unless o.Force is set, because it would issue the same
+// numbers again.
+func (m *Manager) SetOverride(series string, o Override) error {
+	if strings.TrimSpace(o.Reason) == "" {
+		return fmt.Errorf("a reason is required to override the counter")
 	}
-	if err != nil {
-		return CounterState{}, err
+	if o.Value < 0 {
+		return fmt.Errorf("counter value must be >= 0")
 	}
 
-	var state CounterState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return CounterState{}, err
+	series = SeriesName(series)
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.override(series, o)
 	}
-	return state, nil
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		// A corrupt or missing state is rebuilt and then overridden for this
+		// series; that is how an administrator repairs it. A tampered state
+		// must be acknowledged first.
+		if errors.Is(loadErr, ErrTampered) {
+			return nil, loadErr
+		}
+		if loadErr != nil {
+			if err := m.rebuildState(state, loadErr); err != nil {
+				return nil, err
+			}
+		}
+		st := state.series(series)
+		previous := st.Current
+		// Compare against the highest number ever issued, not the current
+		// value, which an earlier override may have lowered already
+		events, err := m.store.Events()
+		if err != nil {
+			return nil, err
+		}
+		issued, reset := highestIssued(events, series)
+		if !reset {
+			// Numbers of the counter from before series count as issued
+			issued = max(issued, (&CounterState{Legacy: state.Legacy}).series(series).Current)
+		}
+		if o.Value < issued && !o.Force {
+			return nil, fmt.Errorf("%w: %s has issued numbers up to %d", ErrRewind, series, issued)
+		}
+		state.setSeries(series, SeriesState{Current: o.Value, Period: st.Period})
+		return []Event{{
+			Time:     time.Now(),
+			Series:   series,
+			Kind:     EventOverride,
+			Value:    o.Value,
+			Previous: previous,
+			Reason:   o.Reason,
+			Forced:   o.Force && o.Value < issued,
+		}}, nil
+	})
 }
 
-func (m *Manager) saveState(state CounterState) error {
-	data, err := json.MarshalIndent(state, "", "  ")
+// highestIssued returns the highest number of series the ledger shows as
+// used since its last reset: issued, reserved for a batch, voided or
+// carried over from another store. reset reports whether the series was
+// ever reset.
+func highestIssued(events []Event, series string) (high int, reset bool) {
+	for _, e := range events {
+		if e.Series != series {
+			continue
+		}
+		switch e.Kind {
+		case EventReset:
+			high, reset = 0, true
+		case EventIssue, EventMigrate:
+			high = max(high, e.Value)
+		case EventReserve, EventVoid:
+			high = max(high, e.Last)
+		case EventRelease:
+			// The returned end of a block was never handed out
+			if high == e.Last {
+				high = e.First - 1
+			}
+		}
+	}
+	return high, reset
+}
+
+// RecordPINChange records in the ledger that the admin PIN was set or
+// changed. The counter server checks its own PIN, so nothing is recorded
+// there.
+func (m *Manager) RecordPINChange() error {
+	if _, ok := m.store.(*RemoteStore); ok {
+		return nil
+	}
+	return m.recordEvent(Event{Time: time.Now(), Kind: EventAdminPIN})
+}
+
+// PINWasSet reports whether the ledger records that an admin PIN was set
+func (m *Manager) PINWasSet() (bool, error) {
+	events, err := m.store.Events()
 	if err != nil {
-		return err
+		return false, err
+	}
+	for _, e := range events {
+		if e.Kind == EventAdminPIN {
+			return true, nil
+		}
 	}
-	return os.WriteFile(m.statePath, data, 0644)
+	return false, nil
 }
 
-// ForceUnlock cleans up a stale lock file (use with caution, maybe on startup if requested)
-func (m *Manager) ForceUnlock() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// backupReader is implemented by stores that keep a copy of the state
+// before its last update
+type backupReader interface {
+	LoadBackup() (CounterState, error)
+}
 
-	// Check if file status indicates it's very old?
-	// For now, just remove it as requested.
-	if err := os.Remove(m.lockPath); err != nil && !os.IsNotExist(err) {
-		return err
+// rebuildState recovers a corrupt or missing state from the ledger, with
+// the store's backup filling in Legacy and series the ledger does not
+// know. Without either, every other series would start over and issue
+// numbers again, so the repair is refused.
+func (m *Manager) rebuildState(state *CounterState, loadErr error) error {
+	events, err := m.store.Events()
+	if err != nil {
+		return fmt.Errorf("cannot rebuild the counter from the ledger: %w", err)
+	}
+	backup, backupErr := CounterState{}, errors.New("no backup")
+	if b, ok := m.store.(backupReader); ok {
+		backup, backupErr = b.LoadBackup()
+	}
+	if len(events) == 0 && backupErr != nil {
+		return fmt.Errorf("cannot repair the counter, neither the ledger nor a backup holds the other series: %w", loadErr)
+	}
+
+	*state = CounterState{}
+	if backupErr == nil {
+		state.Legacy = backup.Legacy
+		for name, st := range backup.Series {
+			state.setSeries(name, st)
+		}
+	}
+	// The ledger records every change and wins over the older backup
+	for name, st := range replayLedger(events) {
+		state.setSeries(name, st)
 	}
-	m.locked = false
 	return nil
 }
+
+// History returns the ledger events of a series, oldest first
+func (m *Manager) History(series string) ([]Event, error) {
+	series = SeriesName(series)
+	events, err := m.store.Events()
+	if err != nil {
+		return nil, err
+	}
+	var out []Event
+	for _, e := range events {
+		if e.Series == series {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,768 @@
+package counter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the sealing keys of test counters out of the user's config dir
+	dir, err := os.MkdirTemp("", "pdf-freezer-keys-*")
+	if err != nil {
+		panic(err)
+	}
+	keyDir = func() (string, error) { return dir, nil }
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m, err := newManagerAt(t.TempDir())
+	if err != nil {
+		t.Fatalf("newManagerAt failed: %v", err)
+	}
+	return m
+}
+
+// files returns the file store behind a manager created by newManagerAt
+func files(m *Manager) *FileStore {
+	return m.store.(*FileStore)
+}
+
+func TestGetNextPersists(t *testing.T) {
+	m := newTestManager(t)
+	for want := 1; want <= 3; want++ {
+		got, err := m.GetNext("AR")
+		if err != nil {
+			t.Fatalf("GetNext failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected %d, got %d", want, got)
+		}
+	}
+
+	cur, err := m.GetCurrent("AR")
+	if err != nil {
+		t.Fatalf("GetCurrent failed: %v", err)
+	}
+	if cur != 3 {
+		t.Errorf("Expected current 3, got %d", cur)
+	}
+}
+
+func TestCorruptStateRefusesToIssue(t *testing.T) {
+	m := newTestManager(t)
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatalf("GetNext failed: %v", err)
+	}
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatalf("GetNext failed: %v", err)
+	}
+
+	// Simulate a crash that truncated the state file
+	if err := os.WriteFile(files(m).statePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetNext("AR"); !errors.Is(err, ErrCorruptState) {
+		t.Fatalf("Expected ErrCorruptState, got %v", err)
+	}
+
+	// The backup still holds the last good state
+	data, err := os.ReadFile(files(m).statePath + ".bak")
+	if err != nil {
+		t.Fatalf("Backup missing: %v", err)
+	}
+	if len(data) == 0 {
+		t.Error("Backup is empty")
+	}
+
+	// An override repairs the state without clobbering the backup
+	if err := m.SetOverride("AR", Override{Value: 10, Reason: "repair corrupt state"}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	backup, _ := os.ReadFile(files(m).statePath + ".bak")
+	if string(backup) != string(data) {
+		t.Error("Backup was overwritten by corrupt state")
+	}
+	got, err := m.GetNext("AR")
+	if err != nil {
+		t.Fatalf("GetNext after override failed: %v", err)
+	}
+	if got != 11 {
+		t.Errorf("Expected 11, got %d", got)
+	}
+}
+
+func TestMissingStateWithBackupRefusesToIssue(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 2; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatalf("GetNext failed: %v", err)
+		}
+	}
+	if err := os.Remove(files(m).statePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetNext("AR"); !errors.Is(err, ErrStateMissing) {
+		t.Fatalf("Expected ErrStateMissing, got %v", err)
+	}
+}
+
+func TestRepairKeepsOtherSeries(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 7; i++ {
+		if _, err := m.GetNext("GS"); err != nil {
+			t.Fatalf("GetNext failed: %v", err)
+		}
+	}
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatalf("GetNext failed: %v", err)
+	}
+	if err := os.WriteFile(files(m).statePath, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SetOverride("AR", Override{Value: 10, Reason: "repair corrupt state"}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if got, err := m.GetNext("GS"); err != nil || got != 8 {
+		t.Errorf("Expected GS 8 after repair, got %d (%v)", got, err)
+	}
+}
+
+func TestRepairWithoutLedgerOrBackupIsRefused(t *testing.T) {
+	m := newTestManager(t)
+	if err := os.WriteFile(files(m).statePath, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 10, Reason: "repair"}); err == nil {
+		t.Fatal("Expected the repair to be refused")
+	}
+}
+
+func TestConcurrentInstancesIssueUniqueNumbers(t *testing.T) {
+	dir := t.TempDir()
+	a, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const perManager = 25
+	results := make(chan int, 2*perManager)
+	errs := make(chan error, 2)
+	for _, m := range []*Manager{a, b} {
+		go func(m *Manager) {
+			for i := 0; i < perManager; i++ {
+				n, err := m.GetNext("AR")
+				if err != nil {
+					errs <- err
+					return
+				}
+				results <- n
+			}
+			errs <- nil
+		}(m)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("GetNext failed: %v", err)
+		}
+	}
+	close(results)
+
+	seen := map[int]bool{}
+	for n := range results {
+		if seen[n] {
+			t.Fatalf("Number %d issued twice", n)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 2*perManager {
+		t.Errorf("Expected %d numbers, got %d", 2*perManager, len(seen))
+	}
+}
+
+func TestStaleLockIsRecovered(t *testing.T) {
+	m := newTestManager(t)
+	// A crashed process leaves its owner record behind
+	stale := `{"pid":999999,"host":"old-host","acquired":"2026-01-02T03:04:05Z"}`
+	if err := os.WriteFile(files(m).lockPath, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var recovered *LockInfo
+	m.OnStaleLock = func(info LockInfo) { recovered = &info }
+
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatalf("GetNext failed: %v", err)
+	}
+	if recovered == nil || recovered.PID != 999999 || recovered.Host != "old-host" {
+		t.Errorf("Expected stale lock to be reported, got %+v", recovered)
+	}
+
+	data, err := os.ReadFile(files(m).lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected lock owner to be cleared, got %q", data)
+	}
+}
+
+func TestBatchLockBlocksOtherInstance(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := newManagerAt(dir)
+	b, _ := newManagerAt(dir)
+
+	if err := a.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	defer a.Unlock()
+
+	if _, err := a.GetNext("AR"); err != nil {
+		t.Fatalf("GetNext under own lock failed: %v", err)
+	}
+	if err := b.ForceUnlock(); err == nil {
+		t.Error("Expected ForceUnlock to refuse breaking a live lock")
+	}
+}
+
+func TestSeriesAreIndependent(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 3; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := m.GetNext("GS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("Expected GS to start at 1, got %d", got)
+	}
+	if err := m.SetOverride("GS", Override{Value: 41, Reason: "continue paper register"}); err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := m.GetCurrent("AR"); cur != 3 {
+		t.Errorf("Override of GS changed AR to %d", cur)
+	}
+
+	hist, err := m.History("GS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 2 || hist[0].Kind != EventIssue || hist[1].Kind != EventOverride || hist[1].Previous != 1 {
+		t.Errorf("Unexpected GS history: %+v", hist)
+	}
+}
+
+func TestLegacyCounterSeedsNewSeries(t *testing.T) {
+	m := newTestManager(t)
+	// counter.json as written before named series existed
+	if err := os.WriteFile(files(m).statePath, []byte(`{"current": 57}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, series := range []string{"AR", "GS"} {
+		got, err := m.GetNext(series)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != 58 {
+			t.Errorf("Expected %s to continue at 58, got %d", series, got)
+		}
+	}
+}
+
+func TestResetPolicy(t *testing.T) {
+	dec := time.Date(2026, time.December, 31, 23, 0, 0, 0, time.UTC)
+	jan := time.Date(2027, time.January, 1, 8, 0, 0, 0, time.UTC)
+	apr := time.Date(2027, time.April, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		policy    ResetPolicy
+		from, to  time.Time
+		wantReset bool
+	}{
+		{ResetPolicy{Period: ResetNever}, dec, jan, false},
+		{ResetPolicy{Period: ResetYearly}, dec, jan, true},
+		{ResetPolicy{Period: ResetMonthly}, dec, jan, true},
+		{ResetPolicy{Period: ResetMonthly}, jan, jan.AddDate(0, 0, 20), false},
+		{ResetPolicy{Period: ResetYearly, FiscalYearStart: 4}, dec, jan, false},
+		{ResetPolicy{Period: ResetYearly, FiscalYearStart: 4}, jan, apr, true},
+	}
+	for _, tt := range tests {
+		st := SeriesState{Current: 41}
+		tt.policy.applyReset(&st, tt.from)
+		reset, previous := tt.policy.applyReset(&st, tt.to)
+		if reset != tt.wantReset {
+			t.Errorf("%+v %s -> %s: reset = %v, want %v", tt.policy, tt.from, tt.to, reset, tt.wantReset)
+		}
+		if reset && (st.Current != 0 || previous != 41) {
+			t.Errorf("%+v: expected restart from 41, got current %d previous %d", tt.policy, st.Current, previous)
+		}
+	}
+}
+
+func TestResetIsRecordedInLedger(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetResetPolicy(ResetPolicy{Period: ResetYearly}); err != nil {
+		t.Fatal(err)
+	}
+	// Last issued in an earlier year
+	if err := os.WriteFile(files(m).statePath, []byte(`{"series":{"AR":{"current":17,"period":"2001"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := m.GetCurrent("AR"); cur != 0 {
+		t.Errorf("Expected pending reset to preview 0, got %d", cur)
+	}
+
+	var resets []Event
+	m.OnReset = func(e Event) { resets = append(resets, e) }
+	got, err := m.GetNext("AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("Expected 1 after reset, got %d", got)
+	}
+	if len(resets) != 1 || resets[0].Previous != 17 {
+		t.Errorf("Expected one reset from 17, got %+v", resets)
+	}
+
+	hist, _ := m.History("AR")
+	if len(hist) != 2 || hist[0].Kind != EventReset || hist[1].Kind != EventIssue {
+		t.Errorf("Unexpected history: %+v", hist)
+	}
+}
+
+func TestReservationReleasesUnusedNumbers(t *testing.T) {
+	m := newTestManager(t)
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := m.Reserve("AR", 5)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if r.First != 2 || r.Last != 6 {
+		t.Fatalf("Expected block 2-6, got %d-%d", r.First, r.Last)
+	}
+	for want := 2; want <= 3; want++ {
+		if got, _ := r.Next(); got != want {
+			t.Errorf("Expected %d, got %d", want, got)
+		}
+	}
+	if err := r.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if got, _ := m.GetNext("AR"); got != 4 {
+		t.Errorf("Expected released numbers to be reused from 4, got %d", got)
+	}
+}
+
+func TestIssueRangeRunsOnAcrossDocuments(t *testing.T) {
+	m := newTestManager(t)
+	first, last, err := m.IssueRange("bates:ACME", 17, 100, "AR-0042")
+	if err != nil {
+		t.Fatalf("IssueRange failed: %v", err)
+	}
+	if first != 100 || last != 116 {
+		t.Fatalf("Expected pages 100-116, got %d-%d", first, last)
+	}
+	if first, last, _ = m.IssueRange("bates:ACME", 3, 100, "AR-0043"); first != 117 || last != 119 {
+		t.Errorf("Expected pages 117-119, got %d-%d", first, last)
+	}
+
+	history, err := m.History("bates:ACME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Document != "AR-0043" || history[1].First != 117 || history[1].Value != 119 {
+		t.Errorf("Unexpected ledger: %+v", history)
+	}
+}
+
+func TestBatesSeriesIgnoresLegacyCounter(t *testing.T) {
+	m := newTestManager(t)
+	// counter.json as written before named series existed
+	if err := os.WriteFile(files(m).statePath, []byte(`{"current": 500}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, last, err := m.IssueRange("bates:", 3, 1, "AR0501")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != 1 || last != 3 {
+		t.Errorf("Expected Bates pages 1-3, got %d-%d", first, last)
+	}
+	if got, err := m.GetNext("AR"); err != nil || got != 501 {
+		t.Errorf("Expected the serial series to continue at 501, got %d (%v)", got, err)
+	}
+}
+
+func TestReservationVoidsNumbersItCannotReturn(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := newManagerAt(dir)
+	b, _ := newManagerAt(dir)
+
+	r, err := a.Reserve("AR", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	// Another instance issues after the block
+	if got, _ := b.GetNext("AR"); got != 4 {
+		t.Fatalf("Expected 4 after the block, got %d", got)
+	}
+	if err := r.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := a.GetCurrent("AR"); cur != 4 {
+		t.Errorf("Release must not rewind below issued numbers, current is %d", cur)
+	}
+
+	hist, _ := a.History("AR")
+	last := hist[len(hist)-1]
+	if last.Kind != EventVoid || last.First != 2 || last.Last != 3 {
+		t.Errorf("Expected 2-3 to be voided, got %+v", last)
+	}
+}
+
+func TestOverrideRequiresReasonAndRefusesRewind(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 5; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.SetOverride("AR", Override{Value: 8}); err == nil {
+		t.Error("Override without a reason was accepted")
+	}
+	if err := m.SetOverride("AR", Override{Value: 2, Reason: "typo"}); !errors.Is(err, ErrRewind) {
+		t.Fatalf("Expected ErrRewind, got %v", err)
+	}
+	if cur, _ := m.GetCurrent("AR"); cur != 5 {
+		t.Fatalf("Refused override changed AR to %d", cur)
+	}
+	if err := m.SetOverride("AR", Override{Value: 2, Reason: "void misprinted batch", Force: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	hist, err := m.History("AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := hist[len(hist)-1]
+	if last.Kind != EventOverride || !last.Forced || last.Reason != "void misprinted batch" || last.Previous != 5 {
+		t.Errorf("Unexpected override event: %+v", last)
+	}
+}
+
+func TestOverrideCannotRewindInSteps(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 5; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.SetOverride("AR", Override{Value: 2, Reason: "misprint", Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	// 3 to 5 were issued before the forced rewind
+	if err := m.SetOverride("AR", Override{Value: 1, Reason: "again"}); !errors.Is(err, ErrRewind) {
+		t.Fatalf("Expected ErrRewind below the highest issued number, got %v", err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 4, Reason: "partly back"}); !errors.Is(err, ErrRewind) {
+		t.Fatalf("Expected ErrRewind below the highest issued number, got %v", err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 5, Reason: "back to the register"}); err != nil {
+		t.Fatalf("Override to the highest issued number failed: %v", err)
+	}
+
+	// A raise that issued nothing can be taken back
+	if err := m.SetOverride("AR", Override{Value: 20, Reason: "typo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 5, Reason: "fix typo"}); err != nil {
+		t.Errorf("Lowering an unused raise failed: %v", err)
+	}
+}
+
+func TestPINChangeIsRecorded(t *testing.T) {
+	m := newTestManager(t)
+	if set, err := m.PINWasSet(); err != nil || set {
+		t.Fatalf("Expected no PIN recorded, got %v (%v)", set, err)
+	}
+	if err := m.RecordPINChange(); err != nil {
+		t.Fatal(err)
+	}
+	if set, err := m.PINWasSet(); err != nil || !set {
+		t.Errorf("Expected the PIN to be recorded, got %v (%v)", set, err)
+	}
+}
+
+func TestEditedStateBlocksIssuanceUntilAcknowledged(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 3; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := files(m).statePath
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := []byte(strings.Replace(string(data), `"current": 3`, `"current": 1`, 1))
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.GetNext("AR"); !errors.Is(err, ErrTampered) {
+		t.Fatalf("Expected ErrTampered, got %v", err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 5, Reason: "fix"}); !errors.Is(err, ErrTampered) {
+		t.Fatalf("Override of a tampered state: expected ErrTampered, got %v", err)
+	}
+	if err := m.AcknowledgeTamper("checked with the paper register"); err != nil {
+		t.Fatal(err)
+	}
+	// The ledger shows 3 was issued, so the edit back to 1 is not honoured
+	got, err := m.GetNext("AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 4 {
+		t.Errorf("Expected 4 after acknowledgment, got %d", got)
+	}
+}
+
+func TestRestoredOlderStateIsDetected(t *testing.T) {
+	m := newTestManager(t)
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatal(err)
+	}
+	path := files(m).statePath
+	old, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.GetCurrent("AR")
+	if !errors.Is(err, ErrTampered) || !strings.Contains(err.Error(), "older copy") {
+		t.Fatalf("Expected rollback to be detected, got %v", err)
+	}
+	if err := m.AcknowledgeTamper("restored from backup"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.GetNext("AR"); err != nil || got != 4 {
+		t.Errorf("Expected 4 after acknowledgment, got %d (%v)", got, err)
+	}
+}
+
+func TestUnsealedLegacyStateIsTrustedOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/counter.json", []byte(`{"current": 9}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.GetNext("AR"); err != nil || got != 10 {
+		t.Fatalf("Expected 10, got %d (%v)", got, err)
+	}
+
+	// Once sealed, stripping the seal is tampering
+	if err := os.WriteFile(dir+"/counter.json", []byte(`{"current": 9}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.GetNext("AR"); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected ErrTampered, got %v", err)
+	}
+}
+
+func TestRecreatedKeyDoesNotTrustUnsealedState(t *testing.T) {
+	dir := t.TempDir()
+	m, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleting the key and writing an unsealed older state must not pass
+	// for an upgrade from before sealing
+	if err := os.Remove(files(m).keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/counter.json", []byte(`{"series":{"AR":{"current":2}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := reopened.GetNext("AR"); !errors.Is(err, ErrTampered) {
+		t.Fatalf("Expected ErrTampered, got %d (%v)", n, err)
+	}
+	if err := reopened.AcknowledgeTamper("key lost, checked the register"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.GetNext("AR"); err != nil || got != 11 {
+		t.Errorf("Expected 11 after acknowledgment, got %d (%v)", got, err)
+	}
+}
+
+func TestCrashBeforeStateWriteIsCompleted(t *testing.T) {
+	m := newTestManager(t)
+	for i := 0; i < 3; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := files(m).statePath
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Power loss after the ledger got the events of the next update but
+	// before counter.json did, twice in a row, the second time mid-line
+	crash := func() {
+		t.Helper()
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, before, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	crash()
+	crash()
+	f, err := os.OpenFile(files(m).ledgerPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"time":"2026-`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := m.GetNext("AR")
+	if err != nil {
+		t.Fatalf("Crash reported as %v", err)
+	}
+	if got != 6 {
+		t.Errorf("Expected 6 after the crashed updates issued 4 and 5, got %d", got)
+	}
+	if hist, _ := m.History("AR"); len(hist) != 6 || hist[5].Value != 6 {
+		t.Errorf("Expected 6 readable issue events, got %+v", hist)
+	}
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Errorf("State not sealed again after completing the crash: %v", err)
+	}
+}
+
+func TestKeyIsKeptOutsideStore(t *testing.T) {
+	dir := t.TempDir()
+	m, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatal(err)
+	}
+	key := files(m).keyPath
+	if rel, err := filepath.Rel(dir, key); err == nil && !strings.HasPrefix(rel, "..") {
+		t.Errorf("Key %s is inside the store directory %s", key, dir)
+	}
+	if fi, err := os.Stat(key); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Errorf("Expected key mode 0600, got %v", fi.Mode().Perm())
+	}
+
+	// A key from before is moved out of the store directory
+	data, err := os.ReadFile(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, legacyKeyFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.GetNext("AR"); err != nil || got != 2 {
+		t.Errorf("Expected 2 with the moved key, got %d (%v)", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, legacyKeyFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected the old key file to be gone, got %v", err)
+	}
+}
+
+func TestReadsDuringUpdates(t *testing.T) {
+	// Run with -race: reads must not race with the store's bookkeeping
+	m := newTestManager(t)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if _, err := m.GetNext("AR"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if _, err := m.GetCurrent("AR"); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := m.ListSeries(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,300 @@
+package counter
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pdf-freezer/internal/atomicfile"
+)
+
+// ErrTampered is returned when counter.json fails its integrity check: it
+// was edited outside the app, or an older copy was restored over it. No
+// numbers are issued until an administrator acknowledges it.
+var ErrTampered = errors.New("counter state failed integrity check")
+
+// legacyKeyFile is where the sealing key used to be kept, next to
+// counter.json. It is moved to the key directory when the store is opened.
+const legacyKeyFile = "counter.key"
+
+// keyDir returns the directory holding the keys that seal counter.json.
+// It is outside every counter directory, so whoever can edit a
+// counter.json, such as every user of a shared counter, cannot re-seal
+// it. The keys are readable by the owning user only; on Windows the
+// per-user profile ACL protects them.
+var keyDir = func() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, "pdf-freezer-keys"), nil
+}
+
+// keyPathFor returns the path of the key sealing the counter in dir
+func keyPathFor(dir string) (string, error) {
+	base, err := keyDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(base, 0700); err != nil {
+		return "", fmt.Errorf("failed to create key dir: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(base, "counter-"+hex.EncodeToString(sum[:8])+".key"), nil
+}
+
+// moveLegacyKey moves a key kept next to counter.json in dir to path,
+// unless a key exists there already
+func moveLegacyKey(dir, path string) error {
+	legacy := filepath.Join(dir, legacyKeyFile)
+	data, err := os.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read integrity key: %w", err)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := atomicfile.Replace(path, data, 0600); err != nil {
+			return fmt.Errorf("failed to move integrity key: %w", err)
+		}
+	}
+	if err := os.Remove(legacy); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadOrCreateKey reads the sealing key at path, creating a random one if
+// none exists. created reports whether the key was just created.
+func loadOrCreateKey(path string) (key []byte, created bool, err error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err = hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) < 32 {
+			return nil, false, fmt.Errorf("integrity key %s is invalid", path)
+		}
+		return key, false, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to read integrity key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, false, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		// Another instance created it first
+		return loadOrCreateKey(path)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create integrity key: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return nil, false, fmt.Errorf("failed to write integrity key: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, false, err
+	}
+	return key, true, nil
+}
+
+// stateMAC authenticates state with key, excluding the MAC field itself
+func stateMAC(key []byte, state CounterState) (string, error) {
+	state.MAC = ""
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verify checks the seal of a state read from disk and its position in
+// the ledger. An update that wrote its events to the ledger but crashed
+// before writing the state is completed in state from those events.
+func (s *FileStore) verify(state *CounterState) error {
+	if state.MAC != "" || !s.trustUnsealed {
+		// An unsealed state is only trusted as written before sealing
+		// existed; sealed on the next update
+		want, err := stateMAC(s.key, *state)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(want), []byte(state.MAC)) {
+			switch {
+			case s.keyCreated:
+				return fmt.Errorf("%w: the integrity key %s was missing and has been recreated; for a shared counter, copy it there from a workstation already using the counter", ErrTampered, s.keyPath)
+			case state.MAC == "":
+				return fmt.Errorf("%w: %s is not sealed", ErrTampered, s.statePath)
+			}
+			return fmt.Errorf("%w: %s was modified outside the app", ErrTampered, s.statePath)
+		}
+	}
+
+	size, err := s.ledgerSize()
+	if err != nil {
+		return err
+	}
+	switch {
+	case size > state.LedgerSize:
+		if pending, ok := s.pendingEvents(*state); ok {
+			// The revision stays, so the next update writes the one the
+			// crashed update was to write
+			for _, e := range pending {
+				applyEvent(state, e)
+			}
+			state.LedgerSize = size
+			recordLastIssued(state, pending)
+			return nil
+		}
+		return fmt.Errorf("%w: the ledger has entries newer than %s (last issued: %s); an older copy may have been restored",
+			ErrTampered, s.statePath, lastIssuedOrNone(state.LastIssued))
+	case size < state.LedgerSize:
+		return fmt.Errorf("%w: %s is shorter than recorded in %s", ErrTampered, s.ledgerPath, s.statePath)
+	}
+	return nil
+}
+
+// pendingEvents returns the events after the end of the ledger recorded in
+// state if they are all from the update following it, which crashed
+// before writing its state. A line cut off by the crash is left out.
+func (s *FileStore) pendingEvents(state CounterState) ([]Event, bool) {
+	tail, err := s.ledgerFrom(state.LedgerSize)
+	if err != nil {
+		return nil, false
+	}
+	lines := bytes.Split(tail, []byte{'\n'})
+	var events []Event
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			if i == len(lines)-1 {
+				break // Torn last line
+			}
+			return nil, false
+		}
+		if e.Revision != state.Revision+1 {
+			return nil, false
+		}
+		events = append(events, e)
+	}
+	return events, true
+}
+
+// seal records the ledger position and MAC in state before it is written
+func (s *FileStore) seal(state *CounterState, ledgerSize int64, events []Event) error {
+	state.LedgerSize = ledgerSize
+	recordLastIssued(state, events)
+	mac, err := stateMAC(s.key, *state)
+	if err != nil {
+		return err
+	}
+	state.MAC = mac
+	return nil
+}
+
+// recordLastIssued notes the last number events issued in state
+func recordLastIssued(state *CounterState, events []Event) {
+	for _, e := range events {
+		switch e.Kind {
+		case EventIssue:
+			state.LastIssued = fmt.Sprintf("%s %d", e.Series, e.Value)
+		case EventReserve:
+			state.LastIssued = fmt.Sprintf("%s %d", e.Series, e.Last)
+		}
+	}
+}
+
+func lastIssuedOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// replayLedger rebuilds the value of every series from the ledger
+func replayLedger(events []Event) map[string]SeriesState {
+	var state CounterState
+	for _, e := range events {
+		applyEvent(&state, e)
+	}
+	return state.Series
+}
+
+// applyEvent applies the change of the series e records to state
+func applyEvent(state *CounterState, e Event) {
+	st := state.series(e.Series)
+	switch e.Kind {
+	case EventIssue, EventOverride, EventMigrate:
+		st.Current = e.Value
+	case EventReserve:
+		st.Current = e.Last
+	case EventRelease:
+		st.Current = e.First - 1
+	case EventReset:
+		st = SeriesState{Period: e.Period}
+	default:
+		return
+	}
+	state.setSeries(e.Series, st)
+}
+
+// AcknowledgeTamper accepts a state that failed its integrity check and
+// seals it again, recording reason in the ledger. Series the ledger shows
+// further ahead (after a restored older copy) are moved up to the ledger's
+// value, so no number is issued twice.
+func (m *Manager) AcknowledgeTamper(reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason is required to acknowledge the integrity alert")
+	}
+
+	if _, ok := m.store.(*RemoteStore); ok {
+		return fmt.Errorf("acknowledge the integrity alert on the counter server (pdf-freezer-counterd -acknowledge)")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events, err := m.store.Events()
+	if err != nil {
+		return err
+	}
+	replayed := replayLedger(events)
+
+	return m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if !errors.Is(loadErr, ErrTampered) {
+			return nil, fmt.Errorf("counter state passed its integrity check; nothing to acknowledge")
+		}
+		for name, st := range replayed {
+			if st.Current > state.series(name).Current {
+				state.setSeries(name, st)
+			}
+		}
+		return []Event{{
+			Time:   time.Now(),
+			Kind:   EventAcknowledge,
+			Reason: fmt.Sprintf("%s (%v)", reason, loadErr),
+		}}, nil
+	})
+}
@@ -0,0 +1,132 @@
+package counter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Ledger event kinds
+const (
+	EventIssue    = "issue"
+	EventOverride = "override"
+	EventReset    = "reset"
+	EventReserve  = "reserve" // Block First..Last reserved for a batch
+	EventRelease  = "release" // Unused block First..Last returned to the series
+	EventVoid     = "void"    // Numbers First..Last will never be used
+	// EventAcknowledge records an administrator accepting a state that
+	// failed its integrity check
+	EventAcknowledge = "acknowledge"
+	// EventBates records the Bates page range First..Last of a document
+	// numbered per document; global Bates ranges are issue events
+	EventBates = "bates"
+	// EventMigrate records a series value carried over from the previous
+	// store when the counter backend was switched
+	EventMigrate = "migrate"
+	// EventAdminPIN records that the admin PIN was set or changed, so a
+	// PIN deleted from config.json cannot simply be set anew
+	EventAdminPIN = "admin_pin"
+)
+
+// Event is one entry in the append-only counter ledger (ledger.jsonl)
+type Event struct {
+	Time     time.Time `json:"time"`
+	Series   string    `json:"series"`
+	Kind     string    `json:"kind"`
+	Value    int       `json:"value"`
+	Previous int       `json:"previous,omitempty"`
+	Period   string    `json:"period,omitempty"` // New period of a reset
+	First    int       `json:"first,omitempty"`  // Range of a reserve, release or void
+	Last     int       `json:"last,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Forced   bool      `json:"forced,omitempty"` // Override rewound below issued numbers
+	// Document is the serial of the document a range was issued for
+	Document string `json:"document,omitempty"`
+	// Revision is the state revision the update that recorded the event
+	// writes; see FileStore.Update
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// encodeEvents encodes events as JSON lines
+func encodeEvents(events []Event) ([]byte, error) {
+	var buf []byte
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+	return buf, nil
+}
+
+// appendLedger writes encoded event lines and syncs them to disk.
+// Callers must hold the counter lock so lines from several instances
+// never interleave.
+func (s *FileStore) appendLedger(lines []byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(s.ledgerPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(lines); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return f.Sync()
+}
+
+// ledgerFrom returns the ledger from byte offset on
+func (s *FileStore) ledgerFrom(offset int64) ([]byte, error) {
+	f, err := os.Open(s.ledgerPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// ledgerSize returns the size of the ledger in bytes, 0 if it is missing
+func (s *FileStore) ledgerSize() (int64, error) {
+	fi, err := os.Stat(s.ledgerPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// readLedger returns all ledger events, oldest first.
+// A missing ledger is empty; a torn last line from a crash is skipped.
+func (s *FileStore) readLedger() ([]Event, error) {
+	f, err := os.Open(s.ledgerPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,136 @@
+package counter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockTimeout is how long an issuance waits for another process to release
+// the counter lock before giving up
+const lockTimeout = 10 * time.Second
+
+// lockRetryInterval is the delay between attempts to take a busy lock
+const lockRetryInterval = 50 * time.Millisecond
+
+// errLockBusy is returned by tryLockFile when another process holds the lock
+var errLockBusy = errors.New("lock is held by another process")
+
+// LockInfo identifies the process holding the counter lock.
+// It is written into counter.lock while the lock is held and cleared on
+// release, so a non-empty lock file found by the next holder means the
+// previous one crashed.
+type LockInfo struct {
+	PID      int       `json:"pid"`
+	Host     string    `json:"host"`
+	Acquired time.Time `json:"acquired"`
+}
+
+func (i LockInfo) String() string {
+	return fmt.Sprintf("PID %d on %s since %s", i.PID, i.Host, i.Acquired.Format(time.RFC3339))
+}
+
+// fileLock is an OS-level advisory lock on counter.lock.
+// The OS drops the lock when the holding process exits, so a crashed
+// instance can never block the counter permanently.
+type fileLock struct {
+	f *os.File
+}
+
+// acquireLock takes the exclusive lock on path, waiting up to timeout.
+// If the lock file still carries the info of a previous holder that never
+// released it, that info is returned as stale.
+func acquireLock(path string, timeout time.Duration) (*fileLock, *LockInfo, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockBusy) {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to lock counter: %w", err)
+		}
+		if time.Now().After(deadline) {
+			holder := readLockInfo(f)
+			f.Close()
+			if holder != nil {
+				return nil, nil, fmt.Errorf("counter is locked by another instance (%s)", holder)
+			}
+			return nil, nil, fmt.Errorf("counter is locked by another instance or process")
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	stale := readLockInfo(f)
+
+	host, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Host: host, Acquired: time.Now()}
+	if err := writeLockInfo(f, &info); err != nil {
+		unlockFile(f)
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to record lock owner: %w", err)
+	}
+
+	return &fileLock{f: f}, stale, nil
+}
+
+// release clears the owner info and drops the lock
+func (l *fileLock) release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := writeLockInfo(l.f, nil)
+	if uErr := unlockFile(l.f); err == nil {
+		err = uErr
+	}
+	if cErr := l.f.Close(); err == nil {
+		err = cErr
+	}
+	l.f = nil
+	return err
+}
+
+// readLockInfo returns the owner recorded in the lock file, or nil if the
+// file is empty or unreadable
+func readLockInfo(f *os.File) *LockInfo {
+	st, err := f.Stat()
+	if err != nil || st.Size() == 0 {
+		return nil
+	}
+	buf := make([]byte, st.Size())
+	if _, err := f.ReadAt(buf, 0); err != nil {
+		return nil
+	}
+	var info LockInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil
+	}
+	return &info
+}
+
+// writeLockInfo replaces the lock file content with info, or empties it
+// when info is nil
+func writeLockInfo(f *os.File, info *LockInfo) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if info == nil {
+		return f.Sync()
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,53 @@
+//go:build windows
+
+package counter
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOverlapped addresses a single byte far beyond the lock file content.
+// Windows byte-range locks are mandatory, so locking the info bytes
+// themselves would stop other instances from reading the current owner.
+func lockOverlapped() *windows.Overlapped {
+	return &windows.Overlapped{Offset: 0, OffsetHigh: 1}
+}
+
+// tryLockFile takes a non-blocking exclusive LockFileEx lock on f
+func tryLockFile(f *os.File) error {
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, lockOverlapped())
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLockBusy
+	}
+	return err
+}
+
+// unlockFile releases the LockFileEx lock on f
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockOverlapped())
+}
+
+// stillActive is the exit code GetExitCodeProcess reports for a running
+// process
+const stillActive = 259
+
+// processAlive reports whether a process with pid exists on this host
+func processAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Access denied means the process exists but belongs to someone else
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
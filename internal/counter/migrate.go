@@ -0,0 +1,50 @@
+package counter
+
+import (
+	"fmt"
+	"time"
+)
+
+// Adopt carries the series of src over when switching to this manager's
+// store, so the new store never issues a number src already issued. Every
+// series src is further ahead in is moved up to src's value, recorded as a
+// migrate event with from describing src. A src whose state cannot be read
+// is refused rather than skipped.
+func (m *Manager) Adopt(src *Manager, from string) error {
+	prev, err := src.load()
+	if err != nil {
+		return fmt.Errorf("cannot carry over the counter from %s: %w", from, err)
+	}
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.adopt(prev, from)
+	}
+	return m.adoptState(prev, from)
+}
+
+// adoptState moves every series up to its value in prev
+func (m *Manager) adoptState(prev CounterState, from string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		state.Legacy = max(state.Legacy, prev.Legacy)
+
+		now := time.Now()
+		var events []Event
+		for name, st := range prev.Series {
+			cur := state.series(name)
+			if st.Current <= cur.Current {
+				continue
+			}
+			state.setSeries(name, st)
+			events = append(events, Event{
+				Time: now, Series: name, Kind: EventMigrate, Value: st.Current, Previous: cur.Current,
+				Reason: "carried over from " + from,
+			})
+		}
+		return events, nil
+	})
+}
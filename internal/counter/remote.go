@@ -0,0 +1,224 @@
+package counter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrServerUnavailable is returned when the counter server cannot be
+// reached. No number is issued locally in that case, so workstations never
+// hand out duplicates while offline.
+var ErrServerUnavailable = errors.New("counter server unavailable")
+
+// ErrForbidden is returned when the counter server refuses an override,
+// because the admin PIN is wrong or none is configured on the server
+var ErrForbidden = errors.New("not allowed by the counter server")
+
+// Error codes used in server responses
+const (
+	codeCorrupt   = "corrupt"
+	codeMissing   = "missing"
+	codeTampered  = "tampered"
+	codeRewind    = "rewind"
+	codeForbidden = "forbidden"
+	codeInvalid   = "invalid"
+	codeInternal  = "internal"
+)
+
+// numberRequest is the body of the requests that issue, return or record
+// numbers; each uses the fields it needs
+type numberRequest struct {
+	Series   string `json:"series"`
+	Count    int    `json:"count,omitempty"`
+	Start    int    `json:"start,omitempty"`
+	First    int    `json:"first,omitempty"`
+	Last     int    `json:"last,omitempty"`
+	Document string `json:"document,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// numberResponse is the answer to a numberRequest
+type numberResponse struct {
+	Value int    `json:"value,omitempty"`
+	First int    `json:"first,omitempty"`
+	Last  int    `json:"last,omitempty"`
+	Reset *Event `json:"reset,omitempty"` // Periodic reset that preceded the issue
+}
+
+// overrideRequest is the body of POST /v1/override
+type overrideRequest struct {
+	Series string `json:"series"`
+	Value  int    `json:"value"`
+	Reason string `json:"reason"`
+	Force  bool   `json:"force,omitempty"`
+	PIN    string `json:"pin"`
+}
+
+// adoptRequest is the body of POST /v1/adopt
+type adoptRequest struct {
+	State CounterState `json:"state"`
+	From  string       `json:"from"`
+}
+
+// errorResponse is the body of every failed request
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RemoteStore is a Store backed by a pdf-freezer counter server.
+// The server applies the numbering rules itself: a Manager on top of a
+// RemoteStore sends it operations (issue, reserve, override, ...) instead
+// of changed states, so a client can never rewind or fork the counter.
+// If a request fails after it was sent, the outcome is unknown; at worst a
+// number is skipped, never issued twice.
+type RemoteStore struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewRemoteStore creates a store that talks to the counter server at baseURL
+func NewRemoteStore(baseURL, token string) *RemoteStore {
+	return &RemoteStore{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Load returns the current state from the server
+func (r *RemoteStore) Load() (CounterState, error) {
+	var state CounterState
+	err := r.do(http.MethodGet, "/v1/state", nil, &state)
+	return state, err
+}
+
+// Update implements Store. The server does not accept states from
+// clients; Manager sends it operations instead.
+func (r *RemoteStore) Update(fn UpdateFunc) error {
+	return errors.New("the counter server does not accept state updates")
+}
+
+// Events returns the server's ledger
+func (r *RemoteStore) Events() ([]Event, error) {
+	var events []Event
+	err := r.do(http.MethodGet, "/v1/events", nil, &events)
+	return events, err
+}
+
+// Close implements Store
+func (r *RemoteStore) Close() error {
+	r.client.CloseIdleConnections()
+	return nil
+}
+
+// next issues the next number of a series on the server
+func (r *RemoteStore) next(series string) (int, *Event, error) {
+	var resp numberResponse
+	err := r.do(http.MethodPost, "/v1/next", numberRequest{Series: series}, &resp)
+	return resp.Value, resp.Reset, err
+}
+
+// reserve reserves n numbers of a series on the server
+func (r *RemoteStore) reserve(series string, n int) (first, last int, reset *Event, err error) {
+	var resp numberResponse
+	err = r.do(http.MethodPost, "/v1/reserve", numberRequest{Series: series, Count: n}, &resp)
+	return resp.First, resp.Last, resp.Reset, err
+}
+
+// release returns the unused numbers first..last of a reservation
+func (r *RemoteStore) release(series string, first, last int) error {
+	return r.do(http.MethodPost, "/v1/release", numberRequest{Series: series, First: first, Last: last}, nil)
+}
+
+// issueRange issues n numbers of a series for one document on the server
+func (r *RemoteStore) issueRange(series string, n, start int, document string) (int, int, error) {
+	var resp numberResponse
+	err := r.do(http.MethodPost, "/v1/range", numberRequest{Series: series, Count: n, Start: start, Document: document}, &resp)
+	return resp.First, resp.Last, err
+}
+
+// void records on the server that numbers first..last will never be used
+func (r *RemoteStore) void(series string, first, last int, reason string) error {
+	return r.do(http.MethodPost, "/v1/void", numberRequest{Series: series, First: first, Last: last, Reason: reason}, nil)
+}
+
+// bates records on the server the Bates range of a document
+func (r *RemoteStore) bates(series, document string, first, last int) error {
+	return r.do(http.MethodPost, "/v1/bates", numberRequest{Series: series, First: first, Last: last, Document: document}, nil)
+}
+
+// override asks the server to override a series; it checks the PIN
+func (r *RemoteStore) override(series string, o Override) error {
+	return r.do(http.MethodPost, "/v1/override", overrideRequest{
+		Series: series, Value: o.Value, Reason: o.Reason, Force: o.Force, PIN: o.PIN,
+	}, nil)
+}
+
+// adopt asks the server to carry over the series of prev
+func (r *RemoteStore) adopt(prev CounterState, from string) error {
+	return r.do(http.MethodPost, "/v1/adopt", adoptRequest{State: prev, From: from}, nil)
+}
+
+// do sends a JSON request and decodes a JSON response into out
+func (r *RemoteStore) do(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, r.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		switch e.Code {
+		case codeCorrupt:
+			return fmt.Errorf("%w (on server): %s", ErrCorruptState, e.Message)
+		case codeMissing:
+			return fmt.Errorf("%w (on server): %s", ErrStateMissing, e.Message)
+		case codeTampered:
+			return fmt.Errorf("%w (on server): %s", ErrTampered, e.Message)
+		case codeRewind:
+			return fmt.Errorf("%w (on server): %s", ErrRewind, e.Message)
+		case codeForbidden:
+			return fmt.Errorf("%w: %s", ErrForbidden, e.Message)
+		}
+		if e.Message == "" {
+			e.Message = resp.Status
+		}
+		return fmt.Errorf("counter server error: %s", e.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid counter server response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package counter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reservation is a contiguous block of numbers reserved for a batch.
+// Numbers are handed out in order with Next; whatever is left when the
+// batch ends is given back with Release.
+type Reservation struct {
+	m      *Manager
+	mu     sync.Mutex
+	Series string
+	First  int
+	Last   int
+	next   int // next number Next hands out
+	closed bool
+}
+
+// Reserve atomically takes n consecutive numbers from a series, so no other
+// instance can interleave its own numbers into the batch. The store lock is
+// held for the reservation (see Lock).
+func (m *Manager) Reserve(series string, n int) (*Reservation, error) {
+	series = SeriesName(series)
+	first, last, resetEvt, err := m.reserve(series, n)
+	if err != nil {
+		return nil, err
+	}
+	if resetEvt != nil && m.OnReset != nil {
+		m.OnReset(*resetEvt)
+	}
+	return &Reservation{m: m, Series: series, First: first, Last: last, next: first}, nil
+}
+
+// reserve takes the block of n numbers and returns the reset that
+// preceded it, if any
+func (m *Manager) reserve(series string, n int) (first, last int, resetEvt *Event, err error) {
+	if n < 1 {
+		return 0, 0, nil, fmt.Errorf("reservation size must be >= 1, got %d", n)
+	}
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.reserve(series, n)
+	}
+	if err := m.Lock(); err != nil {
+		return 0, 0, nil, err
+	}
+	defer m.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err = m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		resetEvt = nil
+
+		now := time.Now()
+		st := state.series(series)
+		reset, previous := m.policy.applyReset(&st, now)
+		first = st.Current + 1
+		last = st.Current + n
+		st.Current = last
+		state.setSeries(series, st)
+
+		var events []Event
+		if reset {
+			resetEvt = &Event{Time: now, Series: series, Kind: EventReset, Period: st.Period, Previous: previous}
+			events = append(events, *resetEvt)
+		}
+		return append(events, Event{Time: now, Series: series, Kind: EventReserve, Value: last, First: first, Last: last}), nil
+	})
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return first, last, resetEvt, nil
+}
+
+// Next hands out the next number of the block
+func (r *Reservation) Next() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, fmt.Errorf("reservation %d-%d is already released", r.First, r.Last)
+	}
+	if r.next > r.Last {
+		return 0, fmt.Errorf("reservation %d-%d is exhausted", r.First, r.Last)
+	}
+	n := r.next
+	r.next++
+	return n, nil
+}
+
+// Void records in the ledger that a handed-out number was not used, for
+// example because its file failed to process
+func (r *Reservation) Void(n int, reason string) error {
+	if n < r.First || n > r.Last {
+		return fmt.Errorf("number %d is not part of reservation %d-%d", n, r.First, r.Last)
+	}
+	return r.m.recordEvent(Event{Time: time.Now(), Series: r.Series, Kind: EventVoid, First: n, Last: n, Reason: reason})
+}
+
+// Release gives back the numbers that were never handed out. If nothing
+// was issued from the series after the block, the counter is rewound so
+// the numbers are reused; otherwise they are voided in the ledger, because
+// rewinding would reissue numbers that are already taken.
+// Release is safe to call more than once.
+func (r *Reservation) Release() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.next > r.Last {
+		return nil
+	}
+	return r.m.release(r.Series, r.next, r.Last)
+}
+
+// release returns the unused numbers first..last of a reservation
+func (m *Manager) release(series string, first, last int) error {
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.release(series, first, last)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		now := time.Now()
+		st := state.series(series)
+		if st.Current != last {
+			return []Event{{Time: now, Series: series, Kind: EventVoid, First: first, Last: last, Reason: "unused reservation"}}, nil
+		}
+		st.Current = first - 1
+		state.setSeries(series, st)
+		return []Event{{Time: now, Series: series, Kind: EventRelease, Value: st.Current, First: first, Last: last}}, nil
+	})
+}
+
+// IssueRange takes n consecutive numbers from a series for one document,
+// such as the global Bates numbers of its pages, and records them as one
+// issue of First..Last with the document's serial. Numbers start at least at
+// start. The reset policy does not apply: the range keeps running.
+func (m *Manager) IssueRange(series string, n, start int, document string) (first, last int, err error) {
+	if n < 1 {
+		return 0, 0, fmt.Errorf("range size must be >= 1, got %d", n)
+	}
+
+	series = SeriesName(series)
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.issueRange(series, n, start, document)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err = m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		st := state.series(series)
+		first = max(st.Current+1, start)
+		last = first + n - 1
+		st.Current = last
+		state.setSeries(series, st)
+		return []Event{{Time: time.Now(), Series: series, Kind: EventIssue, Value: last, First: first, Last: last, Document: document}}, nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return first, last, nil
+}
+
+// VoidRange records in the ledger that numbers first..last of a series
+// will never be used, for example because their document failed
+func (m *Manager) VoidRange(series string, first, last int, reason string) error {
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.void(SeriesName(series), first, last, reason)
+	}
+	return m.recordEvent(Event{Time: time.Now(), Series: SeriesName(series), Kind: EventVoid, First: first, Last: last, Reason: reason})
+}
+
+// RecordBates records that pages first..last of the document with the given
+// serial were numbered per document
+func (m *Manager) RecordBates(series, document string, first, last int) error {
+	if r, ok := m.store.(*RemoteStore); ok {
+		return r.bates(SeriesName(series), document, first, last)
+	}
+	return m.recordEvent(Event{Time: time.Now(), Series: SeriesName(series), Kind: EventBates, First: first, Last: last, Document: document})
+}
+
+// recordEvent appends e to the ledger without changing any counter
+func (m *Manager) recordEvent(e Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.store.Update(func(state *CounterState, loadErr error) ([]Event, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return []Event{e}, nil
+	})
+}
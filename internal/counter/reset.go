@@ -0,0 +1,78 @@
+package counter
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reset periods
+const (
+	ResetNever   = "never"
+	ResetYearly  = "yearly"
+	ResetMonthly = "monthly"
+)
+
+// ResetPolicy controls when series automatically restart at 1.
+// The reset happens on the first issuance in a new period and is recorded
+// in the ledger.
+type ResetPolicy struct {
+	Period string // ResetNever, ResetYearly or ResetMonthly
+	// FiscalYearStart is the month (1-12) a yearly period begins in;
+	// 0 or 1 means calendar years
+	FiscalYearStart int
+}
+
+// Validate checks the policy settings
+func (p ResetPolicy) Validate() error {
+	switch p.Period {
+	case "", ResetNever, ResetYearly, ResetMonthly:
+	default:
+		return fmt.Errorf("unknown reset period %q", p.Period)
+	}
+	if p.FiscalYearStart < 0 || p.FiscalYearStart > 12 {
+		return fmt.Errorf("fiscal year start must be a month between 1 and 12, got %d", p.FiscalYearStart)
+	}
+	return nil
+}
+
+// active reports whether the policy ever resets
+func (p ResetPolicy) active() bool {
+	return p.Period == ResetYearly || p.Period == ResetMonthly
+}
+
+// PeriodKey identifies the period t falls into, e.g. "2026" for a calendar
+// year, "2026/04" for a fiscal year starting in April 2026 or "2026-10" for
+// a month. It is empty when the policy never resets.
+func (p ResetPolicy) PeriodKey(t time.Time) string {
+	switch p.Period {
+	case ResetMonthly:
+		return fmt.Sprintf("%04d-%02d", t.Year(), int(t.Month()))
+	case ResetYearly:
+		start := p.FiscalYearStart
+		if start <= 1 {
+			return fmt.Sprintf("%04d", t.Year())
+		}
+		year := t.Year()
+		if int(t.Month()) < start {
+			year--
+		}
+		return fmt.Sprintf("%04d/%02d", year, start)
+	default:
+		return ""
+	}
+}
+
+// applyReset restarts st if t is in a later period than the one it was last
+// issued in. It reports whether a reset happened and the value before it.
+func (p ResetPolicy) applyReset(st *SeriesState, t time.Time) (bool, int) {
+	key := p.PeriodKey(t)
+	previous := st.Current
+	// A series that has never been issued under a policy just adopts the
+	// current period; there is no way to tell which period it belonged to.
+	reset := p.active() && st.Period != "" && st.Period != key
+	if reset {
+		st.Current = 0
+	}
+	st.Period = key
+	return reset, previous
+}
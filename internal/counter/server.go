@@ -0,0 +1,233 @@
+package counter
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// maxRequestBody bounds the size of a request
+const maxRequestBody = 1 << 20
+
+// Server exposes a Manager over HTTP/JSON so several workstations can share
+// one counter. Clients use RemoteStore to talk to it. The server issues
+// every number itself and applies the reset policy, rewind and PIN rules;
+// clients never write the state.
+//
+//	GET  /v1/state     current state
+//	GET  /v1/events    ledger
+//	POST /v1/next      issue the next number of a series
+//	POST /v1/reserve   reserve a block of numbers for a batch
+//	POST /v1/release   return the unused rest of a block
+//	POST /v1/range     issue a range for one document (global Bates)
+//	POST /v1/void      record numbers that will never be used
+//	POST /v1/bates     record the per-document Bates range of a document
+//	POST /v1/override  override a series; needs the admin PIN
+//	POST /v1/adopt     carry over the series of a previous store
+type Server struct {
+	m     *Manager
+	token string
+	mux   *http.ServeMux
+
+	// CheckPIN, if set, verifies the admin PIN sent with an override.
+	// Without it, overrides are refused.
+	CheckPIN func(pin string) bool
+}
+
+// NewServer creates a counter server on top of m. If token is not empty,
+// every request must carry it as a bearer token.
+func NewServer(m *Manager, token string) *Server {
+	s := &Server{m: m, token: token, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /v1/state", s.handleState)
+	s.mux.HandleFunc("GET /v1/events", s.handleEvents)
+	s.mux.HandleFunc("POST /v1/next", s.handleNext)
+	s.mux.HandleFunc("POST /v1/reserve", s.handleReserve)
+	s.mux.HandleFunc("POST /v1/release", s.handleRelease)
+	s.mux.HandleFunc("POST /v1/range", s.handleRange)
+	s.mux.HandleFunc("POST /v1/void", s.handleVoid)
+	s.mux.HandleFunc("POST /v1/bates", s.handleBates)
+	s.mux.HandleFunc("POST /v1/override", s.handleOverride)
+	s.mux.HandleFunc("POST /v1/adopt", s.handleAdopt)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		want := "Bearer " + s.token
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+			writeError(w, http.StatusUnauthorized, codeInvalid, "invalid or missing token")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	state, err := s.m.load()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, state)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := s.m.store.Events()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if events == nil {
+		events = []Event{}
+	}
+	writeJSON(w, events)
+}
+
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request) {
+	var req numberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	n, reset, err := s.m.next(SeriesName(req.Series))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, numberResponse{Value: n, Reset: reset})
+}
+
+func (s *Server) handleReserve(w http.ResponseWriter, r *http.Request) {
+	var req numberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	first, last, reset, err := s.m.reserve(SeriesName(req.Series), req.Count)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, numberResponse{First: first, Last: last, Reset: reset})
+}
+
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	var req numberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.First < 1 || req.Last < req.First {
+		writeError(w, http.StatusBadRequest, codeInvalid, "invalid range")
+		return
+	}
+	writeResult(w, s.m.release(SeriesName(req.Series), req.First, req.Last))
+}
+
+func (s *Server) handleRange(w http.ResponseWriter, r *http.Request) {
+	var req numberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	first, last, err := s.m.IssueRange(req.Series, req.Count, req.Start, req.Document)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, numberResponse{First: first, Last: last})
+}
+
+func (s *Server) handleVoid(w http.ResponseWriter, r *http.Request) {
+	var req numberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.First < 1 || req.Last < req.First {
+		writeError(w, http.StatusBadRequest, codeInvalid, "invalid range")
+		return
+	}
+	writeResult(w, s.m.VoidRange(req.Series, req.First, req.Last, req.Reason))
+}
+
+func (s *Server) handleBates(w http.ResponseWriter, r *http.Request) {
+	var req numberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.First < 1 || req.Last < req.First {
+		writeError(w, http.StatusBadRequest, codeInvalid, "invalid range")
+		return
+	}
+	writeResult(w, s.m.RecordBates(req.Series, req.Document, req.First, req.Last))
+}
+
+func (s *Server) handleOverride(w http.ResponseWriter, r *http.Request) {
+	var req overrideRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if s.CheckPIN == nil {
+		writeError(w, http.StatusForbidden, codeForbidden, "no admin PIN is configured on the counter server")
+		return
+	}
+	if !s.CheckPIN(req.PIN) {
+		// Slow down guessing
+		time.Sleep(time.Second)
+		writeError(w, http.StatusForbidden, codeForbidden, "wrong admin PIN")
+		return
+	}
+	writeResult(w, s.m.SetOverride(req.Series, Override{Value: req.Value, Reason: req.Reason, Force: req.Force}))
+}
+
+func (s *Server) handleAdopt(w http.ResponseWriter, r *http.Request) {
+	var req adoptRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	writeResult(w, s.m.adoptState(req.State, req.From))
+}
+
+// readJSON decodes the request body into v, answering bad requests itself
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalid, err.Error())
+		return false
+	}
+	return true
+}
+
+// writeResult answers a request that returns nothing but its error
+func writeResult(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, struct{}{})
+}
+
+// writeStoreError maps store errors to the codes RemoteStore understands
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCorruptState):
+		writeError(w, http.StatusConflict, codeCorrupt, err.Error())
+	case errors.Is(err, ErrStateMissing):
+		writeError(w, http.StatusConflict, codeMissing, err.Error())
+	case errors.Is(err, ErrTampered):
+		writeError(w, http.StatusConflict, codeTampered, err.Error())
+	case errors.Is(err, ErrRewind):
+		writeError(w, http.StatusConflict, codeRewind, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Code: code, Message: msg})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
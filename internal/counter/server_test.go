@@ -0,0 +1,135 @@
+package counter
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+const testPIN = "4711"
+
+func newTestServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(NewManagerWithStore(store), token)
+	s.CheckPIN = func(pin string) bool { return pin == testPIN }
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRemoteManagersIssueUniqueNumbers(t *testing.T) {
+	srv := newTestServer(t, "secret")
+
+	const workstations, perWorkstation = 3, 10
+	var (
+		mu   sync.Mutex
+		seen = map[int]bool{}
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < workstations; i++ {
+		m := NewManagerWithStore(NewRemoteStore(srv.URL, "secret"))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorkstation; j++ {
+				n, err := m.GetNext("AR")
+				if err != nil {
+					t.Errorf("GetNext failed: %v", err)
+					return
+				}
+				mu.Lock()
+				if seen[n] {
+					t.Errorf("Number %d issued twice", n)
+				}
+				seen[n] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	m := NewManagerWithStore(NewRemoteStore(srv.URL, "secret"))
+	cur, err := m.GetCurrent("AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur != workstations*perWorkstation {
+		t.Errorf("Expected %d, got %d", workstations*perWorkstation, cur)
+	}
+	hist, err := m.History("AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != workstations*perWorkstation {
+		t.Errorf("Expected %d ledger events, got %d", workstations*perWorkstation, len(hist))
+	}
+}
+
+func TestRemoteManagerRefusesWhenOffline(t *testing.T) {
+	srv := newTestServer(t, "")
+	m := NewManagerWithStore(NewRemoteStore(srv.URL, ""))
+	if _, err := m.GetNext("AR"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	if _, err := m.GetNext("AR"); !errors.Is(err, ErrServerUnavailable) {
+		t.Fatalf("Expected ErrServerUnavailable, got %v", err)
+	}
+}
+
+func TestRemoteManagerRejectsWrongToken(t *testing.T) {
+	srv := newTestServer(t, "secret")
+	m := NewManagerWithStore(NewRemoteStore(srv.URL, "wrong"))
+	if _, err := m.GetNext("AR"); err == nil {
+		t.Fatal("Expected an error for a wrong token")
+	}
+}
+
+func TestRemoteOverrideFollowsServerRules(t *testing.T) {
+	srv := newTestServer(t, "")
+	m := NewManagerWithStore(NewRemoteStore(srv.URL, ""))
+	for i := 0; i < 5; i++ {
+		if _, err := m.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.SetOverride("AR", Override{Value: 9, Reason: "skip", PIN: "0000"}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Expected ErrForbidden for a wrong PIN, got %v", err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 2, Reason: "typo", PIN: testPIN}); !errors.Is(err, ErrRewind) {
+		t.Fatalf("Expected ErrRewind, got %v", err)
+	}
+	if err := m.SetOverride("AR", Override{Value: 9, Reason: "paper register", PIN: testPIN}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if got, err := m.GetNext("AR"); err != nil || got != 10 {
+		t.Errorf("Expected 10, got %d (%v)", got, err)
+	}
+}
+
+func TestRemoteReservationIsReleasedOnServer(t *testing.T) {
+	srv := newTestServer(t, "")
+	m := NewManagerWithStore(NewRemoteStore(srv.URL, ""))
+	r, err := m.Reserve("AR", 5)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if cur, err := m.GetCurrent("AR"); err != nil || cur != 2 {
+		t.Errorf("Expected 2 after release, got %d (%v)", cur, err)
+	}
+}
@@ -0,0 +1,137 @@
+package counter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// shareLockStaleAfter is how long a lock file on a network share may go
+// untouched before a holder on another host is presumed dead. Holders touch
+// the file on every update, so only a crashed or disconnected one exceeds it.
+const shareLockStaleAfter = 2 * time.Minute
+
+// heldLock is a counter lock held by this process
+type heldLock interface {
+	release() error
+	// touch signals that the holder is still alive
+	touch()
+}
+
+// lockFunc acquires the counter lock on path, waiting up to timeout, and
+// returns the owner of a recovered stale lock, if any
+type lockFunc func(path string, timeout time.Duration) (heldLock, *LockInfo, error)
+
+// acquireFileLock adapts acquireLock to lockFunc
+func acquireFileLock(path string, timeout time.Duration) (heldLock, *LockInfo, error) {
+	l, stale, err := acquireLock(path, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, stale, nil
+}
+
+func (l *fileLock) touch() {}
+
+// shareLock is a lock file created exclusively on a network share.
+// Advisory OS locks are not reliable across SMB/NFS clients, so the lock is
+// the existence of the file itself, and staleness is decided from the owner
+// record and the file's modification time.
+type shareLock struct {
+	path  string
+	token []byte // content written by this holder
+}
+
+// acquireShareLock creates the lock file exclusively, breaking it if its
+// owner has crashed
+func acquireShareLock(path string, timeout time.Duration) (heldLock, *LockInfo, error) {
+	host, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Host: host, Acquired: time.Now()}
+	token, err := json.Marshal(info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stale *LockInfo
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, wErr := f.Write(token)
+			sErr := f.Sync()
+			f.Close()
+			if wErr != nil || sErr != nil {
+				os.Remove(path)
+				return nil, nil, fmt.Errorf("failed to record lock owner: %v", errors.Join(wErr, sErr))
+			}
+			return &shareLock{path: path, token: token}, stale, nil
+		}
+		if !os.IsExist(err) {
+			return nil, nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		holder, content, isStale := inspectShareLock(path)
+		if isStale {
+			// Only remove the file if it still holds what we inspected, so
+			// a lock freshly taken by someone else is not broken
+			if current, err := os.ReadFile(path); err == nil && string(current) == string(content) {
+				if os.Remove(path) == nil {
+					stale = holder
+				}
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			if holder != nil {
+				return nil, nil, fmt.Errorf("counter is locked by another instance (%s)", holder)
+			}
+			return nil, nil, fmt.Errorf("counter is locked by another instance or process")
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// inspectShareLock reads the owner of an existing lock file and decides
+// whether it is stale
+func inspectShareLock(path string) (*LockInfo, []byte, bool) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(content, &info); err != nil {
+		// Owner is still writing its record, or crashed while doing so
+		return nil, content, time.Since(st.ModTime()) > shareLockStaleAfter
+	}
+
+	host, _ := os.Hostname()
+	if info.Host == host {
+		return &info, content, !processAlive(info.PID)
+	}
+	return &info, content, time.Since(st.ModTime()) > shareLockStaleAfter
+}
+
+// release removes the lock file if it is still ours
+func (l *shareLock) release() error {
+	current, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	if string(current) != string(l.token) {
+		return fmt.Errorf("lock file was taken over by another instance")
+	}
+	return os.Remove(l.path)
+}
+
+func (l *shareLock) touch() {
+	now := time.Now()
+	_ = os.Chtimes(l.path, now, now)
+}
@@ -0,0 +1,169 @@
+package counter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables used by SQLiteStore. The state is kept as
+// a single JSON document, like counter.json, so every backend stores
+// exactly the same data.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS counter_state (
+	id   INTEGER PRIMARY KEY CHECK (id = 1),
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS ledger (
+	id    INTEGER PRIMARY KEY AUTOINCREMENT,
+	event TEXT NOT NULL
+);`
+
+// SQLiteStore keeps the counter and its ledger in an embedded SQLite
+// database. State and ledger entries are written in one transaction, so
+// they can never disagree after a crash.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database dir: %w", err)
+	}
+
+	// Wait for other writers instead of failing, and fsync every commit
+	dsn := (&url.URL{
+		Scheme:   "file",
+		Opaque:   path,
+		RawQuery: "_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)",
+	}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open counter database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize counter database: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Load returns the current state
+func (s *SQLiteStore) Load() (CounterState, error) {
+	return loadSQLiteState(context.Background(), s.db)
+}
+
+// Update applies fn inside an immediate transaction, which takes the
+// database write lock before the state is read
+func (s *SQLiteStore) Update(fn UpdateFunc) error {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return fmt.Errorf("failed to lock counter database: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	state, err := loadSQLiteState(ctx, conn)
+	if err != nil && !isRepairable(err) {
+		return err
+	}
+	events, err := fn(&state, err)
+	if err != nil {
+		return err
+	}
+	state.Revision++
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx,
+		"INSERT INTO counter_state (id, data) VALUES (1, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data",
+		string(data)); err != nil {
+		return err
+	}
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "INSERT INTO ledger (event) VALUES (?)", string(line)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// Events returns all ledger events, oldest first
+func (s *SQLiteStore) Events() ([]Event, error) {
+	rows, err := s.db.Query("SELECT event FROM ledger ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("invalid ledger entry: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// queryer is implemented by *sql.DB and *sql.Conn
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func loadSQLiteState(ctx context.Context, q queryer) (CounterState, error) {
+	var data string
+	err := q.QueryRowContext(ctx, "SELECT data FROM counter_state WHERE id = 1").Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Default start at 0 (first GetNext will be 1)
+		return CounterState{}, nil
+	}
+	if err != nil {
+		return CounterState{}, err
+	}
+
+	var state CounterState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return CounterState{}, fmt.Errorf("%w: counter database: %v", ErrCorruptState, err)
+	}
+	return state, nil
+}
@@ -0,0 +1,397 @@
+package counter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pdf-freezer/internal/atomicfile"
+)
+
+// ErrCorruptState is returned when counter.json exists but cannot be parsed.
+// No numbers are issued until the file is repaired or restored from backup.
+var ErrCorruptState = errors.New("counter state is corrupt")
+
+// ErrStateMissing is returned when counter.json is gone but a backup of it
+// exists, which means numbers were issued before and starting over at 0
+// would produce duplicates.
+var ErrStateMissing = errors.New("counter state is missing")
+
+// UpdateFunc modifies state in place and returns the ledger events to record
+// with the change. loadErr is ErrCorruptState or ErrStateMissing if the
+// stored state could not be read; state is then empty and fn decides
+// whether to repair it or to return an error. loadErr is ErrTampered if the
+// state was read but failed its integrity check; state then holds it as read.
+type UpdateFunc func(state *CounterState, loadErr error) ([]Event, error)
+
+// Store persists counter state and its ledger.
+// Update must be atomic across processes: no other update may run between
+// reading the state and writing the result.
+type Store interface {
+	// Load returns the current state
+	Load() (CounterState, error)
+	// Update applies fn to the current state and persists the result
+	// together with the returned events
+	Update(fn UpdateFunc) error
+	// Events returns all ledger events, oldest first
+	Events() ([]Event, error)
+	// Close releases resources held by the store
+	Close() error
+}
+
+// batchLocker is implemented by stores that can hold their lock across
+// several updates
+type batchLocker interface {
+	Lock() error
+	Unlock() error
+	ForceUnlock() error
+}
+
+// isRepairable reports whether err means the state can only be overwritten
+func isRepairable(err error) bool {
+	return errors.Is(err, ErrCorruptState) || errors.Is(err, ErrStateMissing)
+}
+
+// FileStore keeps the counter in counter.json with an append-only
+// ledger.jsonl next to it. Every update runs under an OS-level lock on
+// counter.lock, so several app instances sharing the directory never issue
+// the same number.
+type FileStore struct {
+	lockPath   string
+	statePath  string
+	ledgerPath string
+	keyPath    string
+	lock       lockFunc
+	batchLock  heldLock // held between Lock and Unlock
+
+	// key seals counter.json; see integrity.go
+	key        []byte
+	keyCreated bool
+	// trustUnsealed accepts a state written before sealing existed, until
+	// the first sealed write. Only a fresh key next to an empty ledger is
+	// an upgrade; a key recreated next to a ledger is not.
+	trustUnsealed bool
+
+	// onStale is called when a lock left behind by a crashed process is
+	// taken over
+	onStale func(LockInfo)
+}
+
+// NewFileStore creates a file store in dir
+func NewFileStore(dir string) (*FileStore, error) {
+	return newFileStore(dir, acquireFileLock)
+}
+
+// NewShareStore creates a file store in dir on a network share (SMB/NFS).
+// Advisory OS locks are unreliable there, so it locks by exclusively
+// creating counter.lock and breaks locks whose owner has crashed.
+func NewShareStore(dir string) (*FileStore, error) {
+	return newFileStore(dir, acquireShareLock)
+}
+
+func newFileStore(dir string, lock lockFunc) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config dir: %w", err)
+	}
+	keyPath, err := keyPathFor(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := moveLegacyKey(dir, keyPath); err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		statePath:  filepath.Join(dir, "counter.json"),
+		lockPath:   filepath.Join(dir, "counter.lock"),
+		ledgerPath: filepath.Join(dir, "ledger.jsonl"),
+		keyPath:    keyPath,
+		lock:       lock,
+	}
+	key, created, err := loadOrCreateKey(s.keyPath)
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.ledgerSize()
+	if err != nil {
+		return nil, err
+	}
+	s.key, s.keyCreated, s.trustUnsealed = key, created, created && size == 0
+	return s, nil
+}
+
+// Load returns the current state
+func (s *FileStore) Load() (CounterState, error) {
+	state, err := s.loadState()
+	if errors.Is(err, ErrTampered) {
+		// An update may be writing the ledger; check again under the lock
+		// before reporting tampering
+		err = s.withLock(func() error {
+			var lockedErr error
+			state, lockedErr = s.loadState()
+			return lockedErr
+		})
+	}
+	return state, err
+}
+
+// Update applies fn under the counter lock and persists the result
+func (s *FileStore) Update(fn UpdateFunc) error {
+	return s.withLock(func() error {
+		state, err := s.loadState()
+		if err != nil && !isRepairable(err) && !errors.Is(err, ErrTampered) {
+			return err
+		}
+		clean := err == nil
+		events, err := fn(&state, err)
+		if err != nil {
+			return err
+		}
+		state.Revision++
+		for i := range events {
+			events[i].Revision = state.Revision
+		}
+		lines, err := encodeEvents(events)
+		if err != nil {
+			return err
+		}
+		size, err := s.ledgerSize()
+		if err != nil {
+			return err
+		}
+		if torn, err := s.ledgerTorn(size); err != nil {
+			return err
+		} else if torn {
+			// End the line a crash cut off, so the events stay readable
+			lines = append([]byte{'\n'}, lines...)
+		}
+
+		// The events go to the ledger before the state that records its new
+		// size. A crash in between leaves events of the next revision after
+		// the recorded size, which the next load completes (see verify).
+		if err := s.seal(&state, size+int64(len(lines)), events); err != nil {
+			return err
+		}
+		if err := s.appendLedger(lines); err != nil {
+			return err
+		}
+		if err := s.saveState(state, clean); err != nil {
+			return err
+		}
+		s.keyCreated, s.trustUnsealed = false, false
+		return nil
+	})
+}
+
+// Close implements Store; the file store holds no open resources
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// Events returns all ledger events, oldest first
+func (s *FileStore) Events() ([]Event, error) {
+	return s.readLedger()
+}
+
+// Lock acquires the counter lock and holds it until Unlock
+func (s *FileStore) Lock() error {
+	if s.batchLock != nil {
+		return fmt.Errorf("already locked by this instance")
+	}
+	l, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	s.batchLock = l
+	return nil
+}
+
+// Unlock releases the lock taken by Lock
+func (s *FileStore) Unlock() error {
+	if s.batchLock == nil {
+		return nil
+	}
+	err := s.batchLock.release()
+	s.batchLock = nil
+	if err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// ForceUnlock releases a batch lock held by this instance and clears the
+// owner record of a lock left behind by a crashed process.
+// A lock held by a live process is not broken.
+func (s *FileStore) ForceUnlock() error {
+	if s.batchLock != nil {
+		err := s.batchLock.release()
+		s.batchLock = nil
+		return err
+	}
+
+	l, stale, err := s.lock(s.lockPath, 0)
+	if err != nil {
+		return err
+	}
+	if stale != nil && s.onStale != nil {
+		s.onStale(*stale)
+	}
+	return l.release()
+}
+
+// acquire takes the counter lock and reports a recovered stale lock
+func (s *FileStore) acquire() (heldLock, error) {
+	l, stale, err := s.lock(s.lockPath, lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if stale != nil && s.onStale != nil {
+		s.onStale(*stale)
+	}
+	return l, nil
+}
+
+// withLock runs fn under the counter lock. If a batch lock is already held
+// by this instance, fn runs under it.
+func (s *FileStore) withLock(fn func() error) error {
+	if s.batchLock != nil {
+		s.batchLock.touch()
+		return fn()
+	}
+	l, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	fnErr := fn()
+	if err := l.release(); err != nil && fnErr == nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return fnErr
+}
+
+func (s *FileStore) loadState() (CounterState, error) {
+	backupPath := atomicfile.BackupPath(s.statePath)
+
+	data, err := os.ReadFile(s.statePath)
+	if os.IsNotExist(err) {
+		if _, bErr := os.Stat(backupPath); bErr == nil {
+			return CounterState{}, fmt.Errorf("%w: %s not found but backup %s exists", ErrStateMissing, s.statePath, backupPath)
+		}
+		if size, err := s.ledgerSize(); err == nil && size > 0 {
+			return CounterState{}, fmt.Errorf("%w: %s not found but the ledger %s has entries", ErrStateMissing, s.statePath, s.ledgerPath)
+		}
+		// Default start at 0 (first GetNext will be 1)
+		return CounterState{}, nil
+	}
+	if err != nil {
+		return CounterState{}, err
+	}
+
+	// An empty file is what a crash mid-write typically leaves behind;
+	// never treat it as a fresh counter.
+	if len(bytes.TrimSpace(data)) == 0 {
+		return CounterState{}, fmt.Errorf("%w: %s is empty (last good state: %s)", ErrCorruptState, s.statePath, backupPath)
+	}
+
+	var state CounterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return CounterState{}, fmt.Errorf("%w: %s: %v (last good state: %s)", ErrCorruptState, s.statePath, err, backupPath)
+	}
+	return state, s.verify(&state)
+}
+
+// LoadBackup returns the state as it was before the last update, from the
+// backup kept next to counter.json
+func (s *FileStore) LoadBackup() (CounterState, error) {
+	data, err := os.ReadFile(atomicfile.BackupPath(s.statePath))
+	if err != nil {
+		return CounterState{}, err
+	}
+	var state CounterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return CounterState{}, fmt.Errorf("backup of %s is unreadable: %w", s.statePath, err)
+	}
+	return state, nil
+}
+
+// saveState writes state. The current file is backed up only if it held a
+// valid state, otherwise a corrupt file would overwrite the last good
+// backup.
+func (s *FileStore) saveState(state CounterState, backup bool) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if !backup {
+		return atomicfile.Replace(s.statePath, data, 0644)
+	}
+	return atomicfile.WriteFile(s.statePath, data, 0644)
+}
+
+// ledgerTorn reports whether the ledger of the given size ends in the
+// middle of a line
+func (s *FileStore) ledgerTorn(size int64) (bool, error) {
+	if size == 0 {
+		return false, nil
+	}
+	last, err := s.ledgerFrom(size - 1)
+	if err != nil {
+		return false, err
+	}
+	return len(last) > 0 && last[0] != '\n', nil
+}
+
+// Counter storage backends
+const (
+	BackendFile   = "file"   // counter.json in a local directory
+	BackendSQLite = "sqlite" // embedded SQLite database
+	BackendShare  = "share"  // counter.json on a network share
+	BackendServer = "server" // shared counter server
+)
+
+// StoreConfig selects and configures a counter Store
+type StoreConfig struct {
+	Backend string
+	// Location is the directory (file, share) or database file (sqlite);
+	// empty uses the app config dir
+	Location string
+	// ServerURL and Token configure the server backend
+	ServerURL string
+	Token     string
+}
+
+// OpenStore creates the Store selected by cfg
+func OpenStore(cfg StoreConfig) (Store, error) {
+	location := cfg.Location
+	if location == "" && cfg.Backend != BackendServer {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user config dir: %w", err)
+		}
+		location = filepath.Join(configDir, "pdf-freezer")
+		if cfg.Backend == BackendSQLite {
+			location = filepath.Join(location, "counter.db")
+		}
+	}
+
+	switch cfg.Backend {
+	case "", BackendFile:
+		return NewFileStore(location)
+	case BackendSQLite:
+		return NewSQLiteStore(location)
+	case BackendShare:
+		if cfg.Location == "" {
+			return nil, fmt.Errorf("the share backend needs a directory on the network share")
+		}
+		return NewShareStore(location)
+	case BackendServer:
+		if cfg.ServerURL == "" {
+			return nil, fmt.Errorf("the server backend needs a counter server URL")
+		}
+		return NewRemoteStore(cfg.ServerURL, cfg.Token), nil
+	default:
+		return nil, fmt.Errorf("unknown counter backend %q", cfg.Backend)
+	}
+}
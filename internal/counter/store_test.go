@@ -0,0 +1,112 @@
+package counter
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStoresIssueUniqueNumbers(t *testing.T) {
+	backends := map[string]func(dir string) (Store, error){
+		BackendFile:  func(dir string) (Store, error) { return NewFileStore(dir) },
+		BackendShare: func(dir string) (Store, error) { return NewShareStore(dir) },
+		BackendSQLite: func(dir string) (Store, error) {
+			return NewSQLiteStore(filepath.Join(dir, "counter.db"))
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// Two instances on the same location, as two app processes would be
+			const instances, perInstance = 2, 15
+			var (
+				mu   sync.Mutex
+				seen = map[int]bool{}
+				wg   sync.WaitGroup
+			)
+			for i := 0; i < instances; i++ {
+				store, err := open(dir)
+				if err != nil {
+					t.Fatalf("open failed: %v", err)
+				}
+				m := NewManagerWithStore(store)
+				defer m.Close()
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < perInstance; j++ {
+						n, err := m.GetNext("AR")
+						if err != nil {
+							t.Errorf("GetNext failed: %v", err)
+							return
+						}
+						mu.Lock()
+						if seen[n] {
+							t.Errorf("Number %d issued twice", n)
+						}
+						seen[n] = true
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			store, err := open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := NewManagerWithStore(store)
+			defer m.Close()
+			if cur, _ := m.GetCurrent("AR"); cur != instances*perInstance {
+				t.Errorf("Expected %d, got %d", instances*perInstance, cur)
+			}
+			if hist, _ := m.History("AR"); len(hist) != instances*perInstance {
+				t.Errorf("Expected %d ledger events, got %d", instances*perInstance, len(hist))
+			}
+		})
+	}
+}
+
+func TestAdoptCarriesSeriesToNewStore(t *testing.T) {
+	dir := t.TempDir()
+	old, err := newManagerAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := old.GetNext("AR"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := NewSQLiteStore(filepath.Join(dir, "counter.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManagerWithStore(store)
+	defer m.Close()
+
+	if err := m.Adopt(old, "file "+dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.GetNext("AR"); err != nil || got != 6 {
+		t.Errorf("Expected the new store to continue at 6, got %d (%v)", got, err)
+	}
+	hist, err := m.History("AR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 2 || hist[0].Kind != EventMigrate || hist[0].Value != 5 {
+		t.Errorf("Expected a migrate event to 5, got %+v", hist)
+	}
+
+	// Adopting again never moves a series back
+	if err := m.Adopt(old, "file "+dir); err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := m.GetCurrent("AR"); cur != 6 {
+		t.Errorf("Expected 6 after adopting an older store, got %d", cur)
+	}
+}
@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/signintech/gopdf"
+
+	"pdf-freezer/internal/stamp"
+)
+
+// renderAudit renders the title and rows of an audit sheet with f
+func renderAudit(a stamp.AuditSheet, f stamp.Fields) (string, []stamp.AuditRow, error) {
+	title, err := stamp.Parse(a.Title)
+	if err != nil {
+		return "", nil, err
+	}
+	rows := make([]stamp.AuditRow, len(a.Rows))
+	for i, r := range a.Rows {
+		tmpl, err := stamp.Parse(r.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		rows[i] = stamp.AuditRow{Label: r.Label, Value: tmpl.Render(f)}
+	}
+	return title.Render(f), rows, nil
+}
+
+// addAuditSheet renders an audit sheet with the fields of its document,
+// not of a page, and adds it to writer
+func addAuditSheet(writer *PDFWriter, a stamp.AuditSheet, f stamp.Fields) error {
+	f.Page, f.Bates = 0, ""
+	title, rows, err := renderAudit(a, f)
+	if err != nil {
+		return err
+	}
+	if err := writer.AddAuditPage(title, rows, a.Style); err != nil {
+		return fmt.Errorf("failed to write audit sheet: %w", err)
+	}
+	return nil
+}
+
+// auditUsesHash reports whether an audit sheet shows the input file hash
+func auditUsesHash(a stamp.AuditSheet) bool {
+	templates := []string{a.Title}
+	for _, r := range a.Rows {
+		templates = append(templates, r.Value)
+	}
+	for _, t := range templates {
+		if tmpl, err := stamp.Parse(t); err == nil && tmpl.UsesHash() {
+			return true
+		}
+	}
+	return false
+}
+
+// settingsSummary describes the settings a document is frozen with, for
+// the {settings} token of the audit sheet
+func settingsSummary(opts ProcessOptions, ovs []overlay, series string) string {
+	level := opts.CompressionLevel
+	if level == "" {
+		level = "none"
+	}
+	parts := []string{"series " + series, "compression " + level}
+
+	var stamps []string
+	if opts.Overlay || opts.Marker != "" {
+		for _, ov := range ovs {
+			desc := fmt.Sprintf("%q", ov.tmpl.String())
+			switch {
+			case ov.imagePath != "":
+				desc = "image " + filepath.Base(ov.imagePath)
+			case ov.code.Enabled():
+				desc = ov.code.Type + " " + desc
+			}
+			stamps = append(stamps, desc+" on "+ov.pages.String())
+		}
+	}
+	if len(stamps) > 0 {
+		parts = append(parts, "stamps "+strings.Join(stamps, ", "))
+	} else {
+		parts = append(parts, "no stamps")
+	}
+	if opts.Watermark.Enabled() {
+		parts = append(parts, fmt.Sprintf("watermark %q", opts.Watermark.Text))
+	}
+	if opts.Bates.Enabled() {
+		parts = append(parts, "Bates numbering "+opts.Bates.Mode)
+	}
+	if opts.Bands.Enabled() {
+		parts = append(parts, fmt.Sprintf("bands %g/%g mm", opts.Bands.Header, opts.Bands.Footer))
+	}
+	if opts.Letterhead.Enabled() {
+		lh := opts.Letterhead.Resolved()
+		parts = append(parts, fmt.Sprintf("letterhead %s page %d %s the page, %s", lh.File, lh.Page, lh.Layer, lh.Scale))
+	}
+	if opts.Marker != "" {
+		parts = append(parts, "test mode")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// AddAuditPage adds an A4 page with the title of an audit sheet and its
+// rendered rows, labels in bold and values wrapped to the space right of
+// them. Rows that do not fit continue on another page.
+func (w *PDFWriter) AddAuditPage(title string, rows []stamp.AuditRow, style stamp.Style) error {
+	style = style.Resolved()
+	regular := fontRegular
+	if style.Bold {
+		regular = fontBold
+	}
+	bold, primary := fontBold, regular
+	if style.Font != "" {
+		var err error
+		if primary, err = w.userFont(style.Font); err != nil {
+			return err
+		}
+		bold = primary
+	}
+	c, err := stamp.ParseColor(style.Color)
+	if err != nil {
+		return err
+	}
+
+	const margin = 20 * 72 / 25.4
+	pageW, pageH := gopdf.PageSizeA4.W, gopdf.PageSizeA4.H
+	addPage := func() {
+		w.pdf.AddPageWithOption(gopdf.PageOption{PageSize: gopdf.PageSizeA4})
+		w.pdf.SetTextColor(c.R, c.G, c.B)
+	}
+	addPage()
+
+	// Title and a rule under it
+	titleSize := style.Size * 1.6
+	runs, _, m, err := w.layoutText(title, bold, fontBold, titleSize)
+	if err != nil {
+		return err
+	}
+	y := margin + m.ascent*titleSize
+	if err := w.drawRuns(runs, titleSize, margin, y); err != nil {
+		return err
+	}
+	y += m.descent*titleSize + style.Size/2
+	w.pdf.SetStrokeColor(c.R, c.G, c.B)
+	w.pdf.SetLineWidth(0.5)
+	w.pdf.Line(margin, y, pageW-margin, y)
+	y += style.Size
+
+	// Values start right of the widest label, within a third of the page
+	labelW := 0.0
+	for _, r := range rows {
+		_, lw, _, err := w.layoutText(r.Label, bold, fontBold, style.Size)
+		if err != nil {
+			return err
+		}
+		labelW = max(labelW, lw)
+	}
+	valueX := margin + min(labelW, (pageW-2*margin)/3) + style.Size*1.5
+	lineH := style.Size * 1.4
+	ascent := w.metrics[fontRegular].ascent * style.Size
+
+	for _, r := range rows {
+		lines, err := w.wrapText(r.Value, primary, regular, style.Size, pageW-margin-valueX)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			lines = [][]textRun{nil}
+		}
+		labelRuns, _, _, err := w.layoutText(r.Label, bold, fontBold, style.Size)
+		if err != nil {
+			return err
+		}
+		for i, line := range lines {
+			if y+lineH > pageH-margin {
+				addPage()
+				y = margin
+			}
+			if i == 0 {
+				if err := w.drawRuns(labelRuns, style.Size, margin, y+ascent); err != nil {
+					return err
+				}
+			}
+			if err := w.drawRuns(line, style.Size, valueX, y+ascent); err != nil {
+				return err
+			}
+			y += lineH
+		}
+		y += style.Size / 2
+	}
+	return nil
+}
+
+// wrapText breaks text at spaces into lines no wider than width, laid out
+// as by layoutText. A word wider than width, such as a hash, is broken
+// between characters.
+func (w *PDFWriter) wrapText(text, primary, builtin string, size, width float64) ([][]textRun, error) {
+	measure := func(s string) (float64, error) {
+		_, sw, _, err := w.layoutText(s, primary, builtin, size)
+		return sw, err
+	}
+	var texts []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		nw, err := measure(next)
+		if err != nil {
+			return nil, err
+		}
+		if nw > width && line != "" {
+			texts = append(texts, line)
+			next = word
+			if nw, err = measure(next); err != nil {
+				return nil, err
+			}
+		}
+		for nw > width {
+			head, err := w.fitPrefix(next, primary, builtin, size, width)
+			if err != nil {
+				return nil, err
+			}
+			texts = append(texts, head)
+			next = next[len(head):]
+			if nw, err = measure(next); err != nil {
+				return nil, err
+			}
+		}
+		line = next
+	}
+	if line != "" {
+		texts = append(texts, line)
+	}
+
+	lines := make([][]textRun, len(texts))
+	for i, l := range texts {
+		runs, _, _, err := w.layoutText(l, primary, builtin, size)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = runs
+	}
+	return lines, nil
+}
+
+// fitPrefix returns the longest prefix of word no wider than width, but at
+// least its first character
+func (w *PDFWriter) fitPrefix(word, primary, builtin string, size, width float64) (string, error) {
+	fit := 0
+	for i, r := range word {
+		end := i + utf8.RuneLen(r)
+		_, pw, _, err := w.layoutText(word[:end], primary, builtin, size)
+		if err != nil {
+			return "", err
+		}
+		if pw > width && i > 0 {
+			break
+		}
+		fit = end
+	}
+	return word[:fit], nil
+}
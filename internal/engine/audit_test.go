@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf-freezer/internal/stamp"
+)
+
+func TestAuditPageWrapsOntoMorePages(t *testing.T) {
+	w, err := NewPDFWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// More rows of long values than fit on one A4 page
+	long := strings.Repeat("value that wraps onto further lines ", 8)
+	rows := make([]stamp.AuditRow, 40)
+	for i := range rows {
+		rows[i] = stamp.AuditRow{Label: fmt.Sprintf("Row %d", i+1), Value: long}
+	}
+	if err := w.AddAuditPage("Freeze Record AR0001", rows, stamp.Style{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := w.pdf.GetNumberOfPages(); n < 2 {
+		t.Errorf("Expected the rows to continue on another page, got %d page(s)", n)
+	}
+	out := filepath.Join(t.TempDir(), "audit.pdf")
+	if err := w.Save(out); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(out); err != nil || !strings.HasPrefix(string(data), "%PDF") {
+		t.Errorf("Expected a PDF, got %v", err)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	w, err := NewPDFWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size, width = 10.0, 100.0
+	hash := strings.Repeat("0123456789abcdef", 4)
+	text := "a short line then " + hash + " end"
+	lines, err := w.wrapText(text, fontRegular, fontRegular, size, width)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, line := range lines {
+		text, lw := "", 0.0
+		for _, run := range line {
+			text += run.text
+			lw += run.width
+		}
+		if lw > width {
+			t.Errorf("Line %q is %.1f wide, more than %.0f", text, lw, width)
+		}
+		texts = append(texts, text)
+	}
+	// The hash is broken across lines without losing a character
+	if got := strings.Join(texts, ""); !strings.Contains(got, hash) || !strings.HasSuffix(got, "end") {
+		t.Errorf("Expected all of the hash in order, got %q", texts)
+	}
+	if len(texts) < 4 {
+		t.Errorf("Expected the hash to span several lines, got %q", texts)
+	}
+}
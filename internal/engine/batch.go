@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BatchResult is the outcome of one file of a batch
+type BatchResult struct {
+	InputPath  string `json:"input_path"`
+	OutputPath string `json:"output_path"`
+	Serial     string `json:"serial"`
+	Number     int    `json:"number"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SortJobs puts batch jobs into the order serials are assigned in:
+// case-insensitive by input path, so the same set of files always receives
+// the same numbers regardless of the order they were dropped in
+func SortJobs(jobs []ProcessOptions) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := strings.ToLower(jobs[i].InputPath), strings.ToLower(jobs[j].InputPath)
+		if a != b {
+			return a < b
+		}
+		return jobs[i].InputPath < jobs[j].InputPath
+	})
+}
+
+// ProcessBatch freezes jobs with one contiguous block of serials reserved up
+// front, so another instance cannot interleave numbers into the batch.
+// Jobs are processed in SortJobs order and must share prefix, series and
+// serial format. The number of a file that fails is voided; if ctx is
+// cancelled, the numbers not yet used are returned to the counter.
+// Results are in processing order; files skipped by a cancellation carry
+// the context error.
+func (p *Pipeline) ProcessBatch(ctx context.Context, jobs []ProcessOptions) ([]BatchResult, error) {
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	if err := p.gs.CheckDependencies(); err != nil {
+		return nil, err
+	}
+
+	jobs = append([]ProcessOptions(nil), jobs...)
+	SortJobs(jobs)
+
+	num, err := resolveNumbering(jobs[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs[1:] {
+		if job.Prefix != jobs[0].Prefix || job.Series != jobs[0].Series || job.SerialFormat != jobs[0].SerialFormat || job.CheckDigits != jobs[0].CheckDigits {
+			return nil, fmt.Errorf("all files of a batch must use the same prefix, series and serial format")
+		}
+	}
+	for _, job := range jobs {
+		if _, err := resolveOverlay(job); err != nil {
+			return nil, err
+		}
+		if _, _, err := resolveWatermark(job); err != nil {
+			return nil, err
+		}
+		if err := job.Bates.Validate(); err != nil {
+			return nil, err
+		}
+		if err := checkFonts(job); err != nil {
+			return nil, err
+		}
+		if err := job.Bands.Validate(); err != nil {
+			return nil, err
+		}
+		if err := job.Audit.Validate(); err != nil {
+			return nil, err
+		}
+		if _, _, err := resolveLetterhead(job); err != nil {
+			return nil, err
+		}
+		if err := checkCodes(job, num); err != nil {
+			return nil, err
+		}
+	}
+
+	reservation, err := p.counter.Reserve(num.series, len(jobs))
+	if err != nil {
+		return nil, fmt.Errorf("counter error: %w", err)
+	}
+	// Give back whatever was not handed out, e.g. after a cancellation
+	defer reservation.Release()
+
+	results := make([]BatchResult, len(jobs))
+	for i, job := range jobs {
+		results[i] = BatchResult{InputPath: job.InputPath, OutputPath: job.OutputPath}
+		if err := ctx.Err(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		n, err := reservation.Next()
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		now := time.Now()
+		results[i].Number = n
+		results[i].Serial = num.render(n, now)
+
+		if err := p.freeze(ctx, job, results[i].Serial, now); err != nil {
+			results[i].Error = err.Error()
+			if vErr := reservation.Void(n, err.Error()); vErr != nil {
+				return results, fmt.Errorf("failed to void %s: %w", results[i].Serial, vErr)
+			}
+		}
+	}
+
+	if err := reservation.Release(); err != nil {
+		return results, fmt.Errorf("failed to release unused numbers: %w", err)
+	}
+	return results, ctx.Err()
+}
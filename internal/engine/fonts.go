@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/signintech/gopdf/fontmaker/core"
+
+	"pdf-freezer/internal/stamp"
+)
+
+// glyphSet is the characters a font has glyphs for
+type glyphSet struct {
+	chars  map[int]uint
+	groups []core.CmapFormat12GroupingTable // Characters beyond the BMP
+}
+
+// has reports whether the font has a glyph for r
+func (g glyphSet) has(r rune) bool {
+	if _, ok := g.chars[int(r)]; ok {
+		return true
+	}
+	for _, gr := range g.groups {
+		if uint(r) >= gr.StartCharCode && uint(r) <= gr.EndCharCode {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFont reads the vertical metrics and the glyph coverage of a
+// TrueType font
+func parseFont(data []byte) (fontMetrics, glyphSet, error) {
+	var p core.TTFParser
+	if err := p.ParseFontData(data); err != nil {
+		return fontMetrics{}, glyphSet{}, err
+	}
+	upem := float64(p.UnitsPerEm())
+	m := fontMetrics{
+		ascent:     float64(p.Ascender()) / upem,
+		descent:    -float64(p.Descender()) / upem,
+		typoAscent: float64(p.TypoAscender()) / upem,
+	}
+	return m, glyphSet{chars: p.Chars(), groups: p.GroupingTables()}, nil
+}
+
+// CheckFont reports whether data is a font the writer can embed: TrueType
+// outlines with a Unicode character map. OpenType fonts with CFF outlines
+// and font collections are not supported.
+func CheckFont(data []byte) error {
+	if _, _, err := parseFont(data); err != nil {
+		return fmt.Errorf("unsupported font, TrueType outlines required: %w", err)
+	}
+	return nil
+}
+
+// checkFonts loads every font a job refers to, so a missing or unsupported
+// font is reported before a number is drawn
+func checkFonts(opts ProcessOptions) error {
+	if err := stamp.ValidateFallback(opts.FontFallback); err != nil {
+		return err
+	}
+	names := append([]string(nil), opts.FontFallback...)
+	if len(opts.Layers) == 0 {
+		names = append(names, opts.Style.Font)
+	}
+	for _, l := range opts.Layers {
+		names = append(names, l.Style.Font)
+	}
+	if opts.Watermark.Enabled() {
+		names = append(names, opts.Watermark.Font)
+	}
+	if opts.Bates.Enabled() {
+		names = append(names, opts.Bates.Style.Font)
+	}
+	if opts.Audit.Enabled() {
+		names = append(names, opts.Audit.Style.Font)
+	}
+
+	checked := make(map[string]bool)
+	for _, name := range names {
+		if name == "" || checked[name] {
+			continue
+		}
+		checked[name] = true
+		data, err := os.ReadFile(filepath.Join(opts.FontDir, name))
+		if err != nil {
+			return fmt.Errorf("font %s: %w", name, err)
+		}
+		if err := CheckFont(data); err != nil {
+			return fmt.Errorf("font %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// addFont registers a font under family with its metrics and glyphs
+func (w *PDFWriter) addFont(family string, data []byte) error {
+	m, g, err := parseFont(data)
+	if err != nil {
+		return fmt.Errorf("failed to read font %s: %w", family, err)
+	}
+	if err := w.pdf.AddTTFFontData(family, data); err != nil {
+		return fmt.Errorf("failed to load font %s: %w", family, err)
+	}
+	w.metrics[family], w.glyphs[family] = m, g
+	return nil
+}
+
+// userFont registers a font file of the font folder on first use and
+// returns its family, which is the file name
+func (w *PDFWriter) userFont(name string) (string, error) {
+	if _, ok := w.metrics[name]; ok {
+		return name, nil
+	}
+	data, err := os.ReadFile(filepath.Join(w.fontDir, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to read font: %w", err)
+	}
+	if err := w.addFont(name, data); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// UseFonts sets the folder font files are looked up in and the fonts
+// tried, in order, for characters missing in a stamp's own font
+func (w *PDFWriter) UseFonts(dir string, fallback []string) error {
+	w.fontDir = dir
+	w.fallback = nil
+	for _, name := range fallback {
+		family, err := w.userFont(name)
+		if err != nil {
+			return err
+		}
+		w.fallback = append(w.fallback, family)
+	}
+	return nil
+}
+
+// textRun is a part of a text drawn in one font
+type textRun struct {
+	family string
+	text   string
+	width  float64
+}
+
+// layoutText splits text into runs, each character in the first font of
+// primary, the fallback list and builtin that has a glyph for it, and
+// measures them at size. Characters no font has are left out. It returns
+// the runs, their total width and the largest ascent and descent of the
+// fonts used.
+func (w *PDFWriter) layoutText(text, primary, builtin string, size float64) ([]textRun, float64, fontMetrics, error) {
+	chain := append([]string{primary}, w.fallback...)
+	if builtin != primary {
+		chain = append(chain, builtin)
+	}
+	pick := func(r rune) string {
+		for _, family := range chain {
+			if w.glyphs[family].has(r) {
+				return family
+			}
+		}
+		return ""
+	}
+
+	var runs []textRun
+	for _, r := range text {
+		family := pick(r)
+		if family == "" {
+			// Not in any font; gopdf would skip it but still measure it
+			continue
+		}
+		if n := len(runs); n > 0 && runs[n-1].family == family {
+			runs[n-1].text += string(r)
+			continue
+		}
+		runs = append(runs, textRun{family: family, text: string(r)})
+	}
+
+	var width float64
+	var m fontMetrics
+	for i := range runs {
+		if err := w.pdf.SetFont(runs[i].family, "", size); err != nil {
+			return nil, 0, fontMetrics{}, fmt.Errorf("failed to set font: %w", err)
+		}
+		rw, err := w.pdf.MeasureTextWidth(runs[i].text)
+		if err != nil {
+			return nil, 0, fontMetrics{}, err
+		}
+		runs[i].width = rw
+		width += rw
+		fm := w.metrics[runs[i].family]
+		m.ascent = max(m.ascent, fm.ascent)
+		m.descent = max(m.descent, fm.descent)
+	}
+	return runs, width, m, nil
+}
+
+// drawRuns draws runs laid out at size one after the other, starting at x
+// on the baseline
+func (w *PDFWriter) drawRuns(runs []textRun, size, x, baseline float64) error {
+	for _, run := range runs {
+		if err := w.pdf.SetFont(run.family, "", size); err != nil {
+			return fmt.Errorf("failed to set font: %w", err)
+		}
+		if err := w.textAt(run.text, run.family, size, x, baseline); err != nil {
+			return err
+		}
+		x += run.width
+	}
+	return nil
+}
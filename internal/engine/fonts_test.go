@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+func TestLayoutTextFallsBackPerCharacter(t *testing.T) {
+	dir := t.TempDir()
+	// Go Mono lacks the check mark, which Inter has; neither has CJK
+	for name, data := range map[string][]byte{"mono.ttf": gomono.TTF, "inter.ttf": InterFontData} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := NewPDFWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.UseFonts(dir, []string{"inter.ttf"}); err != nil {
+		t.Fatal(err)
+	}
+	primary, err := w.userFont("mono.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fallback list comes before the built-in font, which also has ✓
+	runs, width, _, err := w.layoutText("A✓中B", primary, fontBold, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []textRun{{family: "mono.ttf", text: "A"}, {family: "inter.ttf", text: "✓"}, {family: "mono.ttf", text: "B"}}
+	if len(runs) != len(want) {
+		t.Fatalf("Expected %d runs, got %+v", len(want), runs)
+	}
+	for i := range want {
+		if runs[i].family != want[i].family || runs[i].text != want[i].text {
+			t.Errorf("Run %d: expected %s %q, got %s %q", i, want[i].family, want[i].text, runs[i].family, runs[i].text)
+		}
+	}
+	// The character no font has takes no space
+	_, without, _, err := w.layoutText("A✓B", primary, fontBold, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != without {
+		t.Errorf("Expected width %.2f without the missing character, got %.2f", without, width)
+	}
+
+	// Without a fallback list the built-in font is next
+	if err := w.UseFonts(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	runs, _, _, err = w.layoutText("A✓", primary, fontBold, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[1].family != fontBold {
+		t.Errorf("Expected ✓ in the built-in font, got %+v", runs)
+	}
+}
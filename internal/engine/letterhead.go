@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/phpdave11/gofpdi"
+
+	"pdf-freezer/internal/stamp"
+)
+
+// letterhead is a resolved letterhead of a job
+type letterhead struct {
+	path  string
+	pages stamp.PageScope
+	lh    stamp.Letterhead
+}
+
+// template is a letterhead page imported into the output
+type template struct {
+	id   int
+	w, h float64 // Size of the template page in points
+	lh   stamp.Letterhead
+}
+
+// resolveLetterhead validates the letterhead of a job and checks that its
+// template page can be read, so a bad file is reported before a number is
+// drawn
+func resolveLetterhead(opts ProcessOptions) (lh letterhead, ok bool, err error) {
+	if !opts.Letterhead.Enabled() {
+		return letterhead{}, false, nil
+	}
+	if err := opts.Letterhead.Validate(); err != nil {
+		return letterhead{}, false, fmt.Errorf("invalid letterhead: %w", err)
+	}
+	resolved := opts.Letterhead.Resolved()
+	pages, err := stamp.ParsePages(resolved.Pages)
+	if err != nil {
+		return letterhead{}, false, err
+	}
+	path := filepath.Join(opts.ImageDir, resolved.File)
+	if _, _, err := templatePageSize(path, resolved.Page); err != nil {
+		return letterhead{}, false, fmt.Errorf("letterhead %s: %w", resolved.File, err)
+	}
+	return letterhead{path: path, pages: pages, lh: resolved}, true, nil
+}
+
+// templatePageSize returns the media box size of a page of a PDF.
+// gofpdi panics on files it cannot parse; that is returned as an error.
+func templatePageSize(path string, page int) (w, h float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unreadable PDF: %v", r)
+		}
+	}()
+	imp := gofpdi.NewImporter()
+	imp.SetSourceFile(path)
+	if n := imp.GetNumPages(); page > n {
+		return 0, 0, fmt.Errorf("page %d requested, the template has %d", page, n)
+	}
+	box := imp.GetPageSizes()[page]["/MediaBox"]
+	w, h = box["w"], box["h"]
+	if w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("page %d has no size", page)
+	}
+	return w, h, nil
+}
+
+// CheckTemplate reports whether the PDF at path has a page that can be
+// used as a letterhead
+func CheckTemplate(path string) error {
+	_, _, err := templatePageSize(path, 1)
+	return err
+}
+
+// UseLetterhead imports the template page of lh from the PDF at path, to
+// be drawn on the pages AddPage is asked to
+func (w *PDFWriter) UseLetterhead(path string, lh stamp.Letterhead) (err error) {
+	lh = lh.Resolved()
+	tplW, tplH, err := templatePageSize(path, lh.Page)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to import letterhead: %v", r)
+		}
+	}()
+	id := w.pdf.ImportPage(path, lh.Page, "/MediaBox")
+	w.template = &template{id: id, w: tplW, h: tplH, lh: lh}
+	return nil
+}
+
+// drawLetterhead draws the imported template page over area, scaled by
+// its rule
+func (w *PDFWriter) drawLetterhead(area region) {
+	t := w.template
+	x, y, tw, th := t.lh.Frame(t.w, t.h, area.width, area.height)
+	w.pdf.UseImportedTemplate(t.id, x, area.top+y, tw, th)
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/signintech/gopdf"
+
+	"pdf-freezer/internal/stamp"
+)
+
+// writeTemplate writes a one-page A4 letterhead PDF with a colored top strip
+func writeTemplate(t *testing.T, path string) {
+	t.Helper()
+	tpl := &gopdf.GoPdf{}
+	tpl.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	tpl.AddPage()
+	tpl.SetFillColor(200, 0, 0)
+	tpl.RectFromUpperLeftWithStyle(0, 0, gopdf.PageSizeA4.W, 80, "F")
+	if err := tpl.WritePdf(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writePage writes a white US Letter page image at 72 dpi
+func writePage(t *testing.T, path string) {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 612, 792))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	img.Set(306, 396, color.Black)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := jpeg.Encode(f, img, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplatePageSize(t *testing.T) {
+	dir := t.TempDir()
+	tplPath, pagePath := filepath.Join(dir, "letterhead.pdf"), filepath.Join(dir, "page.jpg")
+	writeTemplate(t, tplPath)
+	writePage(t, pagePath)
+
+	w, h, err := templatePageSize(tplPath, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != gopdf.PageSizeA4.W || h != gopdf.PageSizeA4.H {
+		t.Errorf("Expected A4, got %.1f×%.1f", w, h)
+	}
+	if _, _, err := templatePageSize(tplPath, 2); err == nil {
+		t.Error("Page beyond the template accepted")
+	}
+	// gofpdi panics on files it cannot parse
+	if err := CheckTemplate(pagePath); err == nil {
+		t.Error("JPEG accepted as a template")
+	}
+	if _, _, err := resolveLetterhead(ProcessOptions{ImageDir: dir, Letterhead: stamp.Letterhead{File: "missing.pdf"}}); err == nil {
+		t.Error("Missing letterhead accepted")
+	}
+}
+
+func TestLetterheadLayers(t *testing.T) {
+	dir := t.TempDir()
+	tplPath, pagePath := filepath.Join(dir, "letterhead.pdf"), filepath.Join(dir, "page.jpg")
+	writeTemplate(t, tplPath)
+	writePage(t, pagePath)
+
+	for _, layer := range []string{stamp.LetterheadUnder, stamp.LetterheadOver} {
+		w, err := NewPDFWriter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.pdf.SetNoCompression()
+		if err := w.UseLetterhead(tplPath, stamp.Letterhead{File: "letterhead.pdf", Layer: layer}); err != nil {
+			t.Fatal(err)
+		}
+		// The second page is outside the letterhead's pages
+		for i := 0; i < 2; i++ {
+			if err := w.AddPage(pagePath, stamp.Bands{Header: 10}, i == 0, stamp.Watermark{}, nil, 72); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out := filepath.Join(dir, layer+".pdf")
+		if err := w.Save(out); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Under the page, the image is multiplied onto the letterhead
+		if multiply := bytes.Contains(data, []byte("/BM /Multiply")); multiply != (layer == stamp.LetterheadUnder) {
+			t.Errorf("%s: multiply blend mode written: %v", layer, multiply)
+		}
+		if n := bytes.Count(data, []byte("/GOFPDITPL0 Do")); n != 1 {
+			t.Errorf("%s: expected the template on 1 page, drawn %d times", layer, n)
+		}
+	}
+}
@@ -2,12 +2,23 @@ package engine
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"pdf-freezer/internal/config"
 	"pdf-freezer/internal/counter"
+	"pdf-freezer/internal/serial"
+	"pdf-freezer/internal/stamp"
 )
 
 var bufferPool = sync.Pool{
@@ -37,8 +48,251 @@ type ProcessOptions struct {
 	OutputPath       string
 	Overlay          bool
 	Prefix           string
-	Position         string
+	Series           string // Counter series; defaults to Prefix
+	SerialFormat     string // Serial template; defaults to serial.DefaultFormat
+	CheckDigits      string // Check digit scheme appended to the serial; see serial.ParseCheck
+	Placement        stamp.Placement
 	CompressionLevel string // none, low, medium, high
+	OverlayTemplate  string // Overlay text; defaults to stamp.DefaultTemplate
+	OverlayPages     string // Pages that carry the overlay; see stamp.ParsePages
+	Style            stamp.Style
+	Operator         string // User name for the {operator} token
+	// Layers are stamps drawn in order on each page. When empty, the
+	// single overlay above (OverlayTemplate, OverlayPages, Placement and
+	// Style) is used.
+	Layers []stamp.Layer
+	// Bates numbers every page, on top of the other stamps and regardless
+	// of Overlay
+	Bates stamp.Bates
+	// ImageDir is the folder that image stamp and letterhead files are
+	// looked up in
+	ImageDir string
+	// FontDir is the folder that font files are looked up in. FontFallback
+	// lists fonts there tried, in order, for characters missing in a
+	// stamp's font, before the built-in font.
+	FontDir      string
+	FontFallback []string
+	// Watermark is drawn across the page under the stamps, regardless of
+	// Overlay; an empty text means none
+	Watermark stamp.Watermark
+	// Bands extend each page with header and footer bands that top and
+	// bottom stamps are placed in
+	Bands stamp.Bands
+	// Audit adds a page summarizing the freeze before or after the pages
+	Audit stamp.AuditSheet
+	// Letterhead draws a page of a template PDF under or over the page
+	// image
+	Letterhead stamp.Letterhead
+	// Marker is stamped before the serial in test mode, e.g. "TEST". It
+	// turns the overlay on so test output always carries it.
+	Marker string
+}
+
+// numbering holds the resolved serial settings of a job
+type numbering struct {
+	prefix string
+	series string
+	format serial.Format
+	check  serial.Check
+}
+
+// render builds the serial for counter value n issued at t
+func (num numbering) render(n int, t time.Time) string {
+	return num.check.Append(num.format.Render(num.prefix, n, t))
+}
+
+// resolveNumbering applies defaults to the serial settings of opts and
+// validates them, before any number is consumed
+func resolveNumbering(opts ProcessOptions) (numbering, error) {
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = "AR" // Default fallback
+	}
+	series := opts.Series
+	if series == "" {
+		series = prefix
+	}
+	formatStr := opts.SerialFormat
+	if formatStr == "" {
+		formatStr = serial.DefaultFormat
+	}
+	format, err := serial.Parse(formatStr)
+	if err != nil {
+		return numbering{}, err
+	}
+	check, err := serial.ParseCheck(opts.CheckDigits)
+	if err != nil {
+		return numbering{}, err
+	}
+	return numbering{prefix: prefix, series: series, format: format, check: check}, nil
+}
+
+// overlay holds one resolved stamp layer of a job
+type overlay struct {
+	tmpl      stamp.Template
+	pages     stamp.PageScope
+	placement stamp.Placement
+	style     stamp.Style
+	code      stamp.Code // Encodes the rendered template if set
+
+	// Image stamps: the file and its drawn size in points
+	imagePath      string
+	imageW, imageH float64
+}
+
+// resolveOverlay parses the stamp layers of opts, in drawing order
+func resolveOverlay(opts ProcessOptions) ([]overlay, error) {
+	layers := opts.Layers
+	if len(layers) == 0 {
+		layers = []stamp.Layer{{
+			Template:  opts.OverlayTemplate,
+			Pages:     opts.OverlayPages,
+			Placement: opts.Placement,
+			Style:     opts.Style,
+		}}
+	}
+
+	ovs := make([]overlay, len(layers))
+	for i, l := range layers {
+		if err := l.Validate(); err != nil {
+			if len(layers) > 1 {
+				return nil, fmt.Errorf("stamp layer %d: %w", i+1, err)
+			}
+			return nil, fmt.Errorf("invalid overlay: %w", err)
+		}
+		pages, err := stamp.ParsePages(l.Pages)
+		if err != nil {
+			return nil, err
+		}
+		ov := overlay{pages: pages, placement: l.Placement, style: l.Style, code: l.Code}
+		if l.Image.Enabled() {
+			ov.imagePath = filepath.Join(opts.ImageDir, l.Image.File)
+			if ov.imageW, ov.imageH, err = imageSize(ov.imagePath, l.Image); err != nil {
+				return nil, fmt.Errorf("stamp image %s: %w", l.Image.File, err)
+			}
+			ovs[i] = ov
+			continue
+		}
+		text := l.Template
+		if text == "" {
+			text = stamp.DefaultTemplate
+		}
+		if ov.tmpl, err = stamp.Parse(text); err != nil {
+			return nil, err
+		}
+		ovs[i] = ov
+	}
+	return ovs, nil
+}
+
+// checkCodes encodes the code layers of a job with a sample serial, so
+// content a code cannot hold, such as an umlaut in the prefix of a Code
+// 128, is reported before a number is drawn
+func checkCodes(opts ProcessOptions, num numbering) error {
+	ovs, err := resolveOverlay(opts)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	f := stamp.Fields{
+		Serial:   num.render(1, now),
+		Time:     now,
+		FileName: filepath.Base(opts.InputPath),
+		Page:     1,
+		Pages:    1,
+		Operator: opts.Operator,
+		Hash:     strings.Repeat("0", 64),
+	}
+	if opts.Bates.Enabled() {
+		f.Bates = opts.Bates.Number(f.Serial, opts.Bates.Resolved().Start)
+	}
+	f.Settings = settingsSummary(opts, ovs, num.series)
+
+	marked := markedLayers(ovs)
+	for i, ov := range ovs {
+		if !ov.code.Enabled() || ov.imagePath != "" || (!opts.Overlay && (opts.Marker == "" || !marked[i])) {
+			continue
+		}
+		text := ov.tmpl.Render(f)
+		if opts.Marker != "" && marked[i] {
+			text = opts.Marker + " " + text
+		}
+		if _, err := encodeCode(ov.code.Type, text); err != nil {
+			return fmt.Errorf("stamp layer %d: %s cannot encode %q: %w", i+1, ov.code.Type, text, err)
+		}
+	}
+	return nil
+}
+
+// imageSize checks that path is a PNG or JPEG and returns its drawn size
+// in points
+func imageSize(path string, img stamp.Image) (w, h float64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	if format != "png" && format != "jpeg" {
+		return 0, 0, fmt.Errorf("unsupported image format %s (PNG or JPEG)", format)
+	}
+	w, h = img.Size(cfg.Width, cfg.Height)
+	return w, h, nil
+}
+
+// watermark holds the resolved watermark of a job
+type watermark struct {
+	tmpl  stamp.Template
+	pages stamp.PageScope
+	wm    stamp.Watermark
+}
+
+// resolveWatermark parses the watermark of opts; ok is false when there is
+// none
+func resolveWatermark(opts ProcessOptions) (wm watermark, ok bool, err error) {
+	if !opts.Watermark.Enabled() {
+		return watermark{}, false, nil
+	}
+	if err := opts.Watermark.Validate(); err != nil {
+		return watermark{}, false, fmt.Errorf("invalid watermark: %w", err)
+	}
+	resolved := opts.Watermark.Resolved()
+	tmpl, err := stamp.Parse(resolved.Text)
+	if err != nil {
+		return watermark{}, false, err
+	}
+	pages, err := stamp.ParsePages(resolved.Pages)
+	if err != nil {
+		return watermark{}, false, err
+	}
+	return watermark{tmpl: tmpl, pages: pages, wm: resolved}, true, nil
+}
+
+// isText reports whether the layer shows its text rather than an image or
+// a code
+func (ov overlay) isText() bool {
+	return ov.imagePath == "" && !ov.code.Enabled()
+}
+
+// markedLayers returns which layers carry the test marker: the text layers
+// showing the serial, or the first text layer if none does
+func markedLayers(ovs []overlay) []bool {
+	marked := make([]bool, len(ovs))
+	found := false
+	for i, ov := range ovs {
+		if ov.isText() && ov.tmpl.Uses("serial") {
+			marked[i], found = true, true
+		}
+	}
+	for i := 0; !found && i < len(ovs); i++ {
+		if ovs[i].isText() {
+			marked[i], found = true, true
+		}
+	}
+	return marked
 }
 
 // Process executes the freeze pipeline
@@ -48,14 +302,94 @@ func (p *Pipeline) Process(ctx context.Context, opts ProcessOptions) error {
 		return err
 	}
 
-	// 2. Lock Counter (Batch scope? Or per file? Usually per file or batch.
-	// For this single process call, we lock around the number generation.
+	num, err := resolveNumbering(opts)
+	if err != nil {
+		return err
+	}
+	if _, err := resolveOverlay(opts); err != nil {
+		return err
+	}
+	if _, _, err := resolveWatermark(opts); err != nil {
+		return err
+	}
+	if err := opts.Bates.Validate(); err != nil {
+		return err
+	}
+	if err := checkFonts(opts); err != nil {
+		return err
+	}
+	if err := opts.Bands.Validate(); err != nil {
+		return err
+	}
+	if err := opts.Audit.Validate(); err != nil {
+		return err
+	}
+	if _, _, err := resolveLetterhead(opts); err != nil {
+		return err
+	}
+	if err := checkCodes(opts, num); err != nil {
+		return err
+	}
 
-	usageNum, err := p.counter.GetNext()
+	// 2. Draw the next number; the counter locks around the increment
+	usageNum, err := p.counter.GetNext(num.series)
 	if err != nil {
 		return fmt.Errorf("counter error: %w", err)
 	}
 
+	now := time.Now()
+	serialText := num.render(usageNum, now)
+	if err := p.freeze(ctx, opts, serialText, now); err != nil {
+		// The number is spent; record why it has no document
+		if vErr := p.counter.VoidRange(num.series, usageNum, usageNum, err.Error()); vErr != nil {
+			return fmt.Errorf("%w (failed to void %s: %v)", err, serialText, vErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// freeze renders, stamps and re-assembles one document with serialText,
+// issued at the given time
+func (p *Pipeline) freeze(ctx context.Context, opts ProcessOptions, serialText string, issued time.Time) error {
+	ovs, err := resolveOverlay(opts)
+	if err != nil {
+		return err
+	}
+	marked := markedLayers(ovs)
+	wm, hasWatermark, err := resolveWatermark(opts)
+	if err != nil {
+		return err
+	}
+	lh, hasLetterhead, err := resolveLetterhead(opts)
+	if err != nil {
+		return err
+	}
+	fields := stamp.Fields{
+		Serial:   serialText,
+		Time:     issued,
+		FileName: filepath.Base(opts.InputPath),
+		Operator: opts.Operator,
+	}
+	needsHash := hasWatermark && wm.tmpl.UsesHash()
+	for _, ov := range ovs {
+		needsHash = needsHash || ov.tmpl.UsesHash()
+	}
+	audit := opts.Audit.Resolved()
+	if opts.Audit.Enabled() {
+		needsHash = needsHash || auditUsesHash(audit)
+		num, err := resolveNumbering(opts)
+		if err != nil {
+			return err
+		}
+		fields.Settings = settingsSummary(opts, ovs, num.series)
+	}
+	if needsHash {
+		if fields.Hash, err = fileSHA256(opts.InputPath); err != nil {
+			return fmt.Errorf("failed to hash input: %w", err)
+		}
+	}
+
 	// 3. Create Temp Dir for pages
 	tmpDir, err := os.MkdirTemp("", "pdf-freezer-*")
 	if err != nil {
@@ -76,43 +410,127 @@ func (p *Pipeline) Process(ctx context.Context, opts ProcessOptions) error {
 	}
 
 	// 5. Initialize Writer
-	// We need to write the embedded font to a temp file because gopdf requires a path
-	fontTmp, err := os.CreateTemp("", "font-*.ttf")
+	writer, err := NewPDFWriter()
 	if err != nil {
-		return fmt.Errorf("failed to create font temp file: %w", err)
+		return err
 	}
-	defer os.Remove(fontTmp.Name()) // Clean up
-
-	if _, err := fontTmp.Write(InterFontData); err != nil {
-		return fmt.Errorf("failed to write font data: %w", err)
+	if err := writer.UseFonts(opts.FontDir, opts.FontFallback); err != nil {
+		return err
+	}
+	if hasLetterhead {
+		if err := writer.UseLetterhead(lh.path, lh.lh); err != nil {
+			return err
+		}
 	}
-	fontTmp.Close()
-
-	writer := NewPDFWriter(fontTmp.Name())
 
 	// 6. Re-assemble
-	prefix := opts.Prefix
-	if prefix == "" {
-		prefix = "AR" // Default fallback
+	fields.Pages = len(images)
+
+	// Bates numbers, now that the page count is known. Global numbers are
+	// taken from their own series and voided if the document fails.
+	bates := opts.Bates.Resolved()
+	batesFirst, saved := bates.Start, false
+	if bates.Mode == stamp.BatesGlobal {
+		first, last, err := p.counter.IssueRange(bates.Series(), len(images), bates.Start, serialText)
+		if err != nil {
+			return fmt.Errorf("Bates counter error: %w", err)
+		}
+		batesFirst = first
+		defer func() {
+			if !saved {
+				_ = p.counter.VoidRange(bates.Series(), first, last, "document "+serialText+" failed")
+			}
+		}()
+	}
+
+	if audit.Position == stamp.AuditCover {
+		if err := addAuditSheet(writer, audit, fields); err != nil {
+			return err
+		}
 	}
-	serialText := fmt.Sprintf("%s%04d", prefix, usageNum)
 
 	for i, imgPath := range images {
-		// Overlay only on first page
-		txt := ""
-		if i == 0 && opts.Overlay {
-			txt = serialText
+		fields.Page = i + 1
+		if bates.Enabled() {
+			fields.Bates = bates.Number(serialText, batesFirst+i)
+		}
+		var pageWM stamp.Watermark
+		if hasWatermark && wm.pages.Includes(i+1, len(images)) {
+			pageWM = wm.wm
+			pageWM.Text = wm.tmpl.Render(fields)
+		}
+		var stamps []Stamp
+		for j, ov := range ovs {
+			// Without the overlay, test output still gets the marked layers
+			if !opts.Overlay && (opts.Marker == "" || !marked[j]) {
+				continue
+			}
+			if !ov.pages.Includes(i+1, len(images)) {
+				continue
+			}
+			if ov.imagePath != "" {
+				stamps = append(stamps, Stamp{
+					ImagePath: ov.imagePath, Width: ov.imageW, Height: ov.imageH,
+					Placement: ov.placement, Style: ov.style,
+				})
+				continue
+			}
+			txt := ov.tmpl.Render(fields)
+			if opts.Marker != "" && marked[j] {
+				txt = opts.Marker + " " + txt
+			}
+			stamps = append(stamps, Stamp{Text: txt, Code: ov.code, Placement: ov.placement, Style: ov.style})
+		}
+		if bates.Enabled() {
+			stamps = append(stamps, Stamp{Text: fields.Bates, Placement: bates.Placement, Style: bates.Style})
 		}
 
-		if err := writer.AddPage(imgPath, txt, opts.Position, compSettings.DPI); err != nil {
+		pageLH := hasLetterhead && lh.pages.Includes(i+1, len(images))
+		if err := writer.AddPage(imgPath, opts.Bands, pageLH, pageWM, stamps, compSettings.DPI); err != nil {
 			return fmt.Errorf("failed to write page %d: %w", i+1, err)
 		}
 	}
 
+	if audit.Position == stamp.AuditTrailer {
+		if err := addAuditSheet(writer, audit, fields); err != nil {
+			return err
+		}
+	}
+
+	// Global ranges are in the ledger already; record the per-document one.
+	// This comes before saving: once the document exists, its serial must
+	// not be voided.
+	if bates.Mode == stamp.BatesDocument {
+		num, err := resolveNumbering(opts)
+		if err != nil {
+			return err
+		}
+		last := batesFirst + len(images) - 1
+		if err := p.counter.RecordBates(num.series, serialText, batesFirst, last); err != nil {
+			return fmt.Errorf("failed to record Bates range: %w", err)
+		}
+	}
+
 	// 7. Save
 	if err := writer.Save(opts.OutputPath); err != nil {
 		return fmt.Errorf("failed to save output: %w", err)
 	}
+	saved = true
 
 	return nil
 }
+
+// fileSHA256 returns the hex SHA-256 of the file at path
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"testing"
+
+	"pdf-freezer/internal/stamp"
+)
+
+func TestCheckCodesBeforeNumbering(t *testing.T) {
+	opts := ProcessOptions{
+		InputPath: "invoice.pdf",
+		Prefix:    "ÄR",
+		Overlay:   true,
+		Layers:    []stamp.Layer{{Template: "{serial}", Code: stamp.Code{Type: stamp.CodeCode128}}},
+	}
+	num, err := resolveNumbering(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkCodes(opts, num); err == nil {
+		t.Error("Code 128 of a non-ASCII serial accepted")
+	}
+
+	opts.Layers[0].Code.Type = stamp.CodeQR
+	if err := checkCodes(opts, num); err != nil {
+		t.Errorf("QR code of a non-ASCII serial refused: %v", err)
+	}
+	opts.Layers[0].Code.Type = stamp.CodeCode128
+	opts.Overlay = false
+	if err := checkCodes(opts, num); err != nil {
+		t.Errorf("Code layer that is not drawn refused: %v", err)
+	}
+}
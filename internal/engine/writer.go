@@ -3,39 +3,96 @@ package engine
 import (
 	"fmt"
 	"image"
+	"image/color"
 	_ "image/jpeg"
+	_ "image/png"
 	"os"
 
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
 	"github.com/signintech/gopdf"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"pdf-freezer/internal/stamp"
+)
+
+// Font families registered by NewPDFWriter
+const (
+	fontBold    = "Inter"
+	fontRegular = "GoRegular"
 )
 
 // PDFWriter reconstructs the PDF
 type PDFWriter struct {
-	pdf *gopdf.GoPdf
+	pdf      *gopdf.GoPdf
+	metrics  map[string]fontMetrics
+	glyphs   map[string]glyphSet
+	fontDir  string    // Folder of the fonts stamps refer to, see UseFonts
+	fallback []string  // Families tried for characters missing in a font
+	template *template // Letterhead, see UseLetterhead
+}
+
+// fontMetrics are a font's vertical metrics per point of font size
+type fontMetrics struct {
+	ascent     float64 // Baseline to the top of the tallest glyphs
+	descent    float64 // Baseline to the bottom of descenders, positive
+	typoAscent float64 // What gopdf puts above the baseline of a cell
+}
+
+// textAt draws text in the current font with its baseline at (x, baseline).
+// A cell is used rather than Text because only cells carry the current
+// transparency.
+func (w *PDFWriter) textAt(text, family string, size, x, baseline float64) error {
+	w.pdf.SetXY(x, baseline-w.metrics[family].typoAscent*size)
+	return w.pdf.CellWithOption(nil, text, gopdf.CellOption{Align: gopdf.Left | gopdf.Top})
 }
 
-// NewPDFWriter creates a new writer instance
-func NewPDFWriter(fontPath string) *PDFWriter {
+// NewPDFWriter creates a new writer instance with the embedded stamp fonts
+func NewPDFWriter() (*PDFWriter, error) {
 	pdf := &gopdf.GoPdf{}
 
 	// Start document (A4 default, overridden per page)
 	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
 
-	// Load Font
-	// We will use the font provided.
-	if fontPath != "" {
-		err := pdf.AddTTFFont("Inter", fontPath)
-		if err != nil {
-			fmt.Printf("Warning: Failed to load font: %v\n", err)
+	w := &PDFWriter{pdf: pdf, metrics: make(map[string]fontMetrics), glyphs: make(map[string]glyphSet)}
+	for family, data := range map[string][]byte{fontBold: InterFontData, fontRegular: goregular.TTF} {
+		if err := w.addFont(family, data); err != nil {
+			return nil, err
 		}
 	}
+	return w, nil
+}
+
+// Stamp is text, an image or a code drawn over a page
+type Stamp struct {
+	Text      string
+	Code      stamp.Code // Encodes Text instead of showing it if set
+	ImagePath string     // PNG or JPEG drawn instead of Text if set
+	Width     float64    // Image size in points
+	Height    float64
+	Placement stamp.Placement
+	Style     stamp.Style
+}
+
+// region is the part of the page a stamp is placed in: the page image or
+// a header or footer band
+type region struct {
+	top, width, height float64
+}
 
-	return &PDFWriter{pdf: pdf}
+// place returns the upper-left corner of a w×h block placed at p in r
+func (r region) place(p stamp.Placement, w, h float64) (x, y float64) {
+	x, y = p.Place(w, h, r.width, r.height)
+	return x, y + r.top
 }
 
-// AddPage adds a JPEG image as a page.
-// If overlayText is not empty, it prints it at a configured position.
-func (w *PDFWriter) AddPage(imagePath string, overlayText string, position string, dpi int) error {
+// AddPage adds a JPEG image as a page, extended by the header and footer
+// bands if any, with the letterhead under or over the image if letterhead
+// is set, draws the watermark over it if wm has text, then the stamps in
+// order, so later stamps sit on top. The page image is rendered as
+// displayed, so placement follows the visible page orientation.
+func (w *PDFWriter) AddPage(imagePath string, bands stamp.Bands, letterhead bool, wm stamp.Watermark, stamps []Stamp, dpi int) error {
 	// ... decoding config ...
 	f, err := os.Open(imagePath)
 	if err != nil {
@@ -50,57 +107,283 @@ func (w *PDFWriter) AddPage(imagePath string, overlayText string, position strin
 
 	widthPt := float64(cfg.Width) * 72.0 / float64(dpi)
 	heightPt := float64(cfg.Height) * 72.0 / float64(dpi)
+	header, footer := bands.Points()
+	pageH := header + heightPt + footer
 
 	w.pdf.AddPageWithOption(gopdf.PageOption{
-		PageSize: &gopdf.Rect{W: widthPt, H: heightPt},
+		PageSize: &gopdf.Rect{W: widthPt, H: pageH},
 	})
 
-	err = w.pdf.Image(imagePath, 0, 0, &gopdf.Rect{W: widthPt, H: heightPt})
-	if err != nil {
+	if bands.Enabled() && bands.Color != "" {
+		c, err := stamp.ParseColor(bands.Color)
+		if err != nil {
+			return err
+		}
+		w.pdf.SetFillColor(c.R, c.G, c.B)
+		if header > 0 {
+			w.pdf.RectFromUpperLeftWithStyle(0, 0, widthPt, header, "F")
+		}
+		if footer > 0 {
+			w.pdf.RectFromUpperLeftWithStyle(0, header+heightPt, widthPt, footer, "F")
+		}
+	}
+
+	imageArea := region{top: header, width: widthPt, height: heightPt}
+	letterhead = letterhead && w.template != nil
+	under := letterhead && w.template.lh.Layer == stamp.LetterheadUnder
+	if under {
+		w.drawLetterhead(imageArea)
+	}
+	if err := w.drawPageImage(imagePath, imageArea, under); err != nil {
 		return err
 	}
+	if letterhead && !under {
+		w.drawLetterhead(imageArea)
+	}
 
-	if overlayText != "" {
-		if err := w.pdf.SetFont("Inter", "", 12); err != nil {
-			return fmt.Errorf("failed to set font: %w", err)
+	if wm.Enabled() {
+		if err := w.drawWatermark(wm, widthPt, pageH); err != nil {
+			return err
+		}
+	}
+	for _, s := range stamps {
+		top, height := bands.Area(s.Placement, heightPt)
+		area := region{top: top, width: widthPt, height: height}
+		var err error
+		switch {
+		case s.ImagePath != "":
+			err = w.drawImage(s, area)
+		case s.Code.Enabled():
+			err = w.drawCode(s, area)
+		case s.Text != "":
+			err = w.drawStamp(s.Text, s.Placement, s.Style, area)
 		}
-		w.pdf.SetTextColor(255, 0, 0)
-
-		textWidth, err := w.pdf.MeasureTextWidth(overlayText)
 		if err != nil {
 			return err
 		}
+	}
+	return nil
+}
+
+// drawPageImage draws the page image over area. Multiplied onto a
+// letterhead, its white lets the letterhead show through.
+func (w *PDFWriter) drawPageImage(imagePath string, area region, multiply bool) error {
+	rect := &gopdf.Rect{W: area.width, H: area.height}
+	if !multiply {
+		return w.pdf.Image(imagePath, 0, area.top, rect)
+	}
+	holder, err := gopdf.ImageHolderByPath(imagePath)
+	if err != nil {
+		return err
+	}
+	// gopdf leaves out the blend mode of a fully opaque transparency, so
+	// the alpha is a shade under 1, less than one colour level
+	return w.pdf.ImageByHolderWithOptions(holder, gopdf.ImageOptions{
+		Y:            area.top,
+		Rect:         rect,
+		Transparency: &gopdf.Transparency{Alpha: 0.999, BlendModeType: gopdf.Multiply},
+	})
+}
 
-		margin := 1.0
-		var x, y float64
-
-		switch position {
-		case "top-left":
-			x = margin
-			y = margin + 12 // Font height offset approximately
-		case "top-right":
-			x = widthPt - textWidth - margin
-			y = margin + 12
-		case "bottom-left":
-			x = margin
-			y = heightPt - margin
-		case "bottom-right":
-			fallthrough
-		default:
-			x = widthPt - textWidth - margin
-			y = heightPt - margin
+// drawWatermark draws wm centered on the page, sized to the page
+func (w *PDFWriter) drawWatermark(wm stamp.Watermark, pageW, pageH float64) error {
+	wm = wm.Resolved()
+	primary := fontBold
+	if wm.Font != "" {
+		var err error
+		if primary, err = w.userFont(wm.Font); err != nil {
+			return err
 		}
+	}
+	_, unitW, m, err := w.layoutText(wm.Text, primary, fontBold, 1)
+	if err != nil {
+		return err
+	}
+	size := wm.FontSize(unitW, m.ascent+m.descent, pageW, pageH)
+	if size <= 0 {
+		return nil
+	}
+	runs, textW, _, err := w.layoutText(wm.Text, primary, fontBold, size)
+	if err != nil {
+		return err
+	}
+
+	if err := w.pdf.SetTransparency(gopdf.Transparency{Alpha: wm.Opacity, BlendModeType: gopdf.NormalBlendMode}); err != nil {
+		return err
+	}
+	defer w.pdf.ClearTransparency()
+	cx, cy := pageW/2, pageH/2
+	// The q/Q pair of the rotation also keeps the transparency to the
+	// watermark
+	w.pdf.Rotate(wm.Angle, cx, cy)
+	defer w.pdf.RotateReset()
+
+	c, err := stamp.ParseColor(wm.Color)
+	if err != nil {
+		return err
+	}
+	w.pdf.SetTextColor(c.R, c.G, c.B)
+	// Center the text box: half the width left, baseline so that the space
+	// between ascent and descent straddles the center
+	return w.drawRuns(runs, size, cx-textW/2, cy+(m.ascent-m.descent)*size/2)
+}
+
+// drawImage draws an image stamp at its placement; PNG alpha is kept
+func (w *PDFWriter) drawImage(s Stamp, area region) error {
+	style := s.Style.Resolved()
+	holder, err := gopdf.ImageHolderByPath(s.ImagePath)
+	if err != nil {
+		return fmt.Errorf("failed to load stamp image: %w", err)
+	}
+	x, y := area.place(s.Placement, s.Width, s.Height)
+
+	opts := gopdf.ImageOptions{X: x, Y: y, Rect: &gopdf.Rect{W: s.Width, H: s.Height}}
+	if style.Opacity < 1 {
+		opts.Transparency = &gopdf.Transparency{Alpha: style.Opacity, BlendModeType: gopdf.NormalBlendMode}
+	}
+	if style.Rotation != 0 {
+		w.pdf.Rotate(style.Rotation, x+s.Width/2, y+s.Height/2)
+		defer w.pdf.RotateReset()
+	}
+	return w.pdf.ImageByHolderWithOptions(holder, opts)
+}
+
+// drawCode draws the stamp text as a QR code or Code 128 barcode on a white
+// quiet zone, so it scans on dark pages too. The bars are vector
+// rectangles and stay sharp at any zoom.
+func (w *PDFWriter) drawCode(s Stamp, area region) error {
+	code := s.Code.Resolved()
+	bc, err := encodeCode(code.Type, s.Text)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %q: %w", code.Type, s.Text, err)
+	}
+	cols, rows := bc.Bounds().Dx(), bc.Bounds().Dy()
 
-		w.pdf.SetX(x)
-		w.pdf.SetY(y)
+	const mm = 72 / 25.4
+	modW, modH := code.Size*mm/float64(cols), code.Size*mm/float64(rows)
+	quietX, quietY := float64(code.QuietZone())*modW, float64(code.QuietZone())*modW
+	if code.Type == stamp.CodeCode128 {
+		// One row of bars, stretched to the bar height
+		modW = code.Module * mm
+		quietX, quietY = float64(code.QuietZone())*modW, 2*modW
+	}
+	blockW := float64(cols)*modW + 2*quietX
+	blockH := float64(rows)*modH + 2*quietY
+	x, y := area.place(s.Placement, blockW, blockH)
 
-		if err := w.pdf.Cell(nil, overlayText); err != nil {
+	ink := stamp.RGB{}
+	if s.Style.Color != "" {
+		if ink, err = stamp.ParseColor(s.Style.Color); err != nil {
 			return err
 		}
 	}
+
+	w.pdf.Rotate(s.Style.Rotation, x+blockW/2, y+blockH/2)
+	defer w.pdf.RotateReset()
+	w.pdf.SetFillColor(255, 255, 255)
+	w.pdf.RectFromUpperLeftWithStyle(x, y, blockW, blockH, "F")
+	w.pdf.SetFillColor(ink.R, ink.G, ink.B)
+
+	// One rectangle per run of dark modules in a row
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; {
+			if !isDark(bc.At(c, r)) {
+				c++
+				continue
+			}
+			start := c
+			for c < cols && isDark(bc.At(c, r)) {
+				c++
+			}
+			w.pdf.RectFromUpperLeftWithStyle(x+quietX+float64(start)*modW, y+quietY+float64(r)*modH, float64(c-start)*modW, modH, "F")
+		}
+	}
 	return nil
 }
 
+// encodeCode encodes content as a QR code (error correction level M) or a
+// Code 128 barcode, one pixel per module
+func encodeCode(kind, content string) (barcode.Barcode, error) {
+	switch kind {
+	case stamp.CodeQR:
+		return qr.Encode(content, qr.M, qr.Auto)
+	case stamp.CodeCode128:
+		return code128.Encode(content)
+	}
+	return nil, fmt.Errorf("unknown code type %q", kind)
+}
+
+// isDark reports whether a module of an encoded code is a bar
+func isDark(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y < 128
+}
+
+// drawStamp draws text with its optional background box at placement
+func (w *PDFWriter) drawStamp(text string, placement stamp.Placement, style stamp.Style, area region) error {
+	style = style.Resolved()
+	builtin := fontRegular
+	if style.Bold {
+		builtin = fontBold
+	}
+	primary := builtin
+	if style.Font != "" {
+		var err error
+		if primary, err = w.userFont(style.Font); err != nil {
+			return err
+		}
+	}
+	runs, textW, m, err := w.layoutText(text, primary, builtin, style.Size)
+	if err != nil {
+		return err
+	}
+	ascent, descent := m.ascent*style.Size, m.descent*style.Size
+	blockW := textW + 2*style.Padding
+	blockH := ascent + descent + 2*style.Padding
+
+	// Upper-left corner of the block and the text baseline inside it
+	x, y := area.place(placement, blockW, blockH)
+	baseline := y + style.Padding + ascent
+
+	if style.Opacity < 1 {
+		if err := w.pdf.SetTransparency(gopdf.Transparency{Alpha: style.Opacity, BlendModeType: gopdf.NormalBlendMode}); err != nil {
+			return err
+		}
+		defer w.pdf.ClearTransparency()
+	}
+	// Rotate even at 0°: its q/Q pair keeps the transparency to this stamp
+	w.pdf.Rotate(style.Rotation, x+blockW/2, y+blockH/2)
+	defer w.pdf.RotateReset()
+
+	if style.HasBox() {
+		paint := ""
+		if style.BoxColor != "" {
+			c, err := stamp.ParseColor(style.BoxColor)
+			if err != nil {
+				return err
+			}
+			w.pdf.SetFillColor(c.R, c.G, c.B)
+			paint += "F"
+		}
+		if style.BorderColor != "" {
+			c, err := stamp.ParseColor(style.BorderColor)
+			if err != nil {
+				return err
+			}
+			w.pdf.SetStrokeColor(c.R, c.G, c.B)
+			w.pdf.SetLineWidth(style.BorderWidth)
+			paint += "D"
+		}
+		w.pdf.RectFromUpperLeftWithStyle(x, y, blockW, blockH, paint)
+	}
+
+	c, err := stamp.ParseColor(style.Color)
+	if err != nil {
+		return err
+	}
+	w.pdf.SetTextColor(c.R, c.G, c.B)
+	return w.drawRuns(runs, style.Size, x+style.Padding, baseline)
+}
+
 // Save writes the PDF to disk
 func (w *PDFWriter) Save(path string) error {
 	return w.pdf.WritePdf(path)
@@ -0,0 +1,142 @@
+package serial
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Check digit schemes
+const (
+	CheckNone  = "none"
+	CheckLuhn  = "luhn"     // One digit, catches single-digit errors and most swaps
+	CheckMod97 = "mod97-10" // ISO 7064 MOD 97-10, two digits as used by IBAN
+	CheckDamm  = "damm"     // One digit, catches all single-digit errors and adjacent swaps
+)
+
+// Check appends and verifies check digits.
+//
+// Letters count as two-digit numbers (A=10 … Z=35) and other characters
+// such as separators are ignored, so the prefix and the date parts of a
+// serial are covered as well. The check digits are appended to the serial
+// without a separator.
+type Check struct {
+	scheme string
+}
+
+// ParseCheck validates a check digit scheme; "" is the same as CheckNone
+func ParseCheck(scheme string) (Check, error) {
+	switch scheme {
+	case "", CheckNone:
+		return Check{}, nil
+	case CheckLuhn, CheckMod97, CheckDamm:
+		return Check{scheme: scheme}, nil
+	default:
+		return Check{}, fmt.Errorf("unknown check digit scheme %q (none, luhn, mod97-10, damm)", scheme)
+	}
+}
+
+// Enabled reports whether check digits are appended
+func (c Check) Enabled() bool {
+	return c.scheme != ""
+}
+
+// Append returns serial with its check digits
+func (c Check) Append(serial string) string {
+	digits := toDigits(serial)
+	switch c.scheme {
+	case CheckLuhn:
+		return serial + string(luhnDigit(digits))
+	case CheckMod97:
+		return fmt.Sprintf("%s%02d", serial, 98-mod97(digits+"00"))
+	case CheckDamm:
+		return serial + string(dammDigit(digits))
+	}
+	return serial
+}
+
+// Valid reports whether serial ends in correct check digits.
+// Surrounding whitespace and letter case are ignored. Without a scheme
+// there is nothing to verify and every serial is valid.
+func (c Check) Valid(serial string) bool {
+	if !c.Enabled() {
+		return true
+	}
+	serial = strings.ToUpper(strings.TrimSpace(serial))
+	n := 1
+	if c.scheme == CheckMod97 {
+		n = 2
+	}
+	if len(serial) <= n {
+		return false
+	}
+	for _, r := range serial[len(serial)-n:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return c.Append(serial[:len(serial)-n]) == serial
+}
+
+// toDigits maps serial to a digit string for the check digit algorithms
+func toDigits(serial string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(serial) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			fmt.Fprintf(&b, "%d", r-'A'+10)
+		}
+	}
+	return b.String()
+}
+
+// luhnDigit returns the Luhn check digit of digits
+func luhnDigit(digits string) byte {
+	sum := 0
+	double := true // The rightmost payload digit is doubled
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// mod97 returns digits modulo 97
+func mod97(digits string) int {
+	r := 0
+	for i := 0; i < len(digits); i++ {
+		r = (r*10 + int(digits[i]-'0')) % 97
+	}
+	return r
+}
+
+// dammTable is the totally anti-symmetric quasigroup of order 10
+var dammTable = [10][10]byte{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+// dammDigit returns the Damm check digit of digits
+func dammDigit(digits string) byte {
+	var interim byte
+	for i := 0; i < len(digits); i++ {
+		interim = dammTable[interim][digits[i]-'0']
+	}
+	return '0' + interim
+}
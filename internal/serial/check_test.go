@@ -0,0 +1,52 @@
+package serial
+
+import "testing"
+
+func TestCheckAppend(t *testing.T) {
+	tests := []struct {
+		scheme string
+		serial string
+		want   string
+	}{
+		{CheckNone, "AR0042", "AR0042"},
+		{CheckLuhn, "7992739871", "79927398713"},
+		{CheckDamm, "572", "5724"},
+		{CheckMod97, "794", "79444"},
+		// Letters count as 10-35, separators are ignored
+		{CheckLuhn, "AR-0042", "AR-0042" + string(luhnDigit("10270042"))},
+	}
+	for _, tt := range tests {
+		c, err := ParseCheck(tt.scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Append(tt.serial); got != tt.want {
+			t.Errorf("%s: Append(%q) = %q, want %q", tt.scheme, tt.serial, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDetectsTypos(t *testing.T) {
+	for _, scheme := range []string{CheckLuhn, CheckMod97, CheckDamm} {
+		c, _ := ParseCheck(scheme)
+		good := c.Append("AR-2026-00417")
+		if !c.Valid(good) || !c.Valid(" ar-2026-00417"+good[len("AR-2026-00417"):]+" ") {
+			t.Errorf("%s: %q should be valid", scheme, good)
+		}
+		for _, typo := range []string{
+			c.Append("AR-2026-00417")[:9] + "1" + good[10:], // 0 -> 1
+			"AR-2026-00471" + good[len("AR-2026-00417"):],   // adjacent swap
+			"AR-2026-00417",
+		} {
+			if c.Valid(typo) {
+				t.Errorf("%s: typo %q accepted", scheme, typo)
+			}
+		}
+	}
+}
+
+func TestParseCheckRejectsUnknown(t *testing.T) {
+	if _, err := ParseCheck("crc32"); err == nil {
+		t.Error("ParseCheck should reject an unknown scheme")
+	}
+}
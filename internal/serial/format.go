@@ -0,0 +1,144 @@
+// Package serial renders serial numbers from a configurable format template.
+package serial
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultFormat reproduces the classic "AR0042" serial
+const DefaultFormat = "{prefix}{n:4}"
+
+// maxPadding is the largest supported zero-padding width for {n}
+const maxPadding = 12
+
+// Format is a parsed serial format template.
+//
+// Supported tokens:
+//
+//	{prefix}  the configured prefix
+//	{n}       the counter value; {n:5} zero-pads it to 5 digits
+//	{yyyy}    four-digit year
+//	{yy}      two-digit year
+//	{mm}      two-digit month
+//	{dd}      two-digit day
+//
+// Everything outside braces is copied literally, so separators and a suffix
+// are written as plain text, e.g. "{prefix}-{yyyy}-{n:5}/K".
+type Format struct {
+	template string
+	parts    []part
+}
+
+// part is either literal text or a token
+type part struct {
+	literal string
+	token   string
+	width   int
+}
+
+// Parse validates a format template. It must contain exactly one {n}.
+func Parse(tmpl string) (Format, error) {
+	f := Format{template: tmpl}
+	counters := 0
+
+	rest := tmpl
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			f.parts = append(f.parts, part{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return Format{}, fmt.Errorf("unexpected '}' in serial format %q", tmpl)
+		}
+		if open > 0 {
+			f.parts = append(f.parts, part{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return Format{}, fmt.Errorf("unclosed '{' in serial format %q", tmpl)
+		}
+		p, err := parseToken(rest[open+1 : open+end])
+		if err != nil {
+			return Format{}, fmt.Errorf("serial format %q: %w", tmpl, err)
+		}
+		if p.token == "n" {
+			counters++
+		}
+		f.parts = append(f.parts, p)
+		rest = rest[open+end+1:]
+	}
+
+	if counters != 1 {
+		return Format{}, fmt.Errorf("serial format %q must contain {n} exactly once", tmpl)
+	}
+	return f, nil
+}
+
+func parseToken(tok string) (part, error) {
+	name, arg, hasArg := strings.Cut(tok, ":")
+	switch name {
+	case "n":
+		p := part{token: "n"}
+		if hasArg {
+			w, err := strconv.Atoi(arg)
+			if err != nil || w < 1 || w > maxPadding {
+				return part{}, fmt.Errorf("invalid padding %q for {n} (1-%d)", arg, maxPadding)
+			}
+			p.width = w
+		}
+		return p, nil
+	case "prefix", "yyyy", "yy", "mm", "dd":
+		if hasArg {
+			return part{}, fmt.Errorf("token {%s} takes no argument", name)
+		}
+		return part{token: name}, nil
+	default:
+		return part{}, fmt.Errorf("unknown token {%s}", tok)
+	}
+}
+
+// String returns the template the format was parsed from
+func (f Format) String() string {
+	return f.template
+}
+
+// Uses reports whether the format contains any of the given tokens,
+// named without braces, e.g. "yyyy"
+func (f Format) Uses(tokens ...string) bool {
+	for _, p := range f.parts {
+		for _, tok := range tokens {
+			if p.token == tok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Render builds the serial for counter value n issued at t
+func (f Format) Render(prefix string, n int, t time.Time) string {
+	var b strings.Builder
+	for _, p := range f.parts {
+		switch p.token {
+		case "":
+			b.WriteString(p.literal)
+		case "prefix":
+			b.WriteString(prefix)
+		case "n":
+			fmt.Fprintf(&b, "%0*d", p.width, n)
+		case "yyyy":
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case "yy":
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case "mm":
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case "dd":
+			fmt.Fprintf(&b, "%02d", t.Day())
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	at := time.Date(2026, time.March, 7, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format string
+		n      int
+		want   string
+	}{
+		{DefaultFormat, 42, "AR0042"},
+		{DefaultFormat, 12345, "AR12345"},
+		{"{prefix}-{yyyy}-{n:5}", 42, "AR-2026-00042"},
+		{"{prefix}{yy}{mm}/{n}", 7, "AR2603/7"},
+		{"{n:3}-{dd}.{mm}.{yyyy}-X", 5, "005-07.03.2026-X"},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.format)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.format, err)
+		}
+		if got := f.Render("AR", tt.n, at); got != tt.want {
+			t.Errorf("Render(%q, %d) = %q, want %q", tt.format, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, tmpl := range []string{
+		"",
+		"{prefix}",
+		"{n}{n}",
+		"{prefix}{n",
+		"{prefix}}{n}",
+		"{n:0}",
+		"{n:x}",
+		"{nr}",
+		"{yyyy:2}{n}",
+	} {
+		if _, err := Parse(tmpl); err == nil {
+			t.Errorf("Parse(%q) should fail", tmpl)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package stamp
+
+import "fmt"
+
+// Audit sheet positions
+const (
+	AuditCover   = "cover"   // Before the first page
+	AuditTrailer = "trailer" // After the last page
+)
+
+// DefaultAuditTitle heads an audit sheet without a title
+const DefaultAuditTitle = "Freeze Record {serial}"
+
+// AuditRow is one line of an audit sheet: a label and a template rendered
+// with the document's fields
+type AuditRow struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// DefaultAuditRows are the rows of an audit sheet without rows of its own
+var DefaultAuditRows = []AuditRow{
+	{Label: "Serial", Value: "{serial}"},
+	{Label: "Frozen", Value: "{date} {time:15:04:05 MST}"},
+	{Label: "Operator", Value: "{operator}"},
+	{Label: "Source file", Value: "{filename}"},
+	{Label: "Source pages", Value: "{pages}"},
+	{Label: "Source SHA-256", Value: "{hash}"},
+	{Label: "Settings", Value: "{settings}"},
+}
+
+// AuditSheet is a generated page summarizing the freeze, added to the
+// output as a cover or trailer page. Its templates are rendered like a
+// stamp's, with {pages} the page count of the source document.
+type AuditSheet struct {
+	Position string     `json:"position"` // cover or trailer; empty for none
+	Title    string     `json:"title"`    // Template; default DefaultAuditTitle
+	Rows     []AuditRow `json:"rows"`     // Default DefaultAuditRows
+	Style    Style      `json:"style"`    // Row text; the title is bold and larger
+}
+
+// Enabled reports whether an audit sheet is added
+func (a AuditSheet) Enabled() bool {
+	return a.Position != ""
+}
+
+// Resolved returns a with defaults filled in for zero values
+func (a AuditSheet) Resolved() AuditSheet {
+	if a.Title == "" {
+		a.Title = DefaultAuditTitle
+	}
+	if len(a.Rows) == 0 {
+		a.Rows = DefaultAuditRows
+	}
+	if a.Style.Color == "" {
+		a.Style.Color = "#000000"
+	}
+	if a.Style.Size == 0 {
+		a.Style.Size = 10
+	}
+	return a
+}
+
+// Validate checks the position, templates and style
+func (a AuditSheet) Validate() error {
+	switch a.Position {
+	case "", AuditCover, AuditTrailer:
+	default:
+		return fmt.Errorf("unknown audit sheet position %q (cover or trailer)", a.Position)
+	}
+	if len(a.Rows) > 40 {
+		return fmt.Errorf("audit sheet has %d rows, at most 40 fit a page", len(a.Rows))
+	}
+	if _, err := Parse(a.Title); err != nil {
+		return err
+	}
+	for i, r := range a.Rows {
+		if _, err := Parse(r.Value); err != nil {
+			return fmt.Errorf("audit row %d: %w", i+1, err)
+		}
+	}
+	if err := a.Style.Validate(); err != nil {
+		return fmt.Errorf("invalid audit sheet style: %w", err)
+	}
+	return nil
+}
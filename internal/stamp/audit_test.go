@@ -0,0 +1,23 @@
+package stamp
+
+import "testing"
+
+func TestAuditSheet(t *testing.T) {
+	a := AuditSheet{Position: AuditTrailer}.Resolved()
+	if a.Title != DefaultAuditTitle || len(a.Rows) != len(DefaultAuditRows) || a.Style.Size != 10 {
+		t.Errorf("Unexpected defaults: %+v", a)
+	}
+	for _, r := range a.Rows {
+		if _, err := Parse(r.Value); err != nil {
+			t.Errorf("Default row %q: %v", r.Label, err)
+		}
+	}
+
+	if err := (AuditSheet{Position: "appendix"}).Validate(); err == nil {
+		t.Error("Unknown position accepted")
+	}
+	bad := AuditSheet{Position: AuditCover, Rows: []AuditRow{{Label: "Hash", Value: "{sha}"}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("Row with an unknown token accepted")
+	}
+}
@@ -0,0 +1,56 @@
+package stamp
+
+import "fmt"
+
+// Bands extend each page with a blank header band above and a footer band
+// below the page image, so stamps can go there instead of over the
+// content. Stamps anchored to the top go in the header band and those
+// anchored to the bottom in the footer band, placed as if the band were
+// the page; the others stay on the image. Heights are in mm; 0 means no
+// band.
+type Bands struct {
+	Header float64 `json:"header"`
+	Footer float64 `json:"footer"`
+	Color  string  `json:"color"` // Band fill, "#RRGGBB" or a basic color name; empty for white
+}
+
+// Enabled reports whether the page is extended at all
+func (b Bands) Enabled() bool {
+	return b.Header > 0 || b.Footer > 0
+}
+
+// Validate checks the heights and color
+func (b Bands) Validate() error {
+	if b.Header < 0 || b.Header > 100 || b.Footer < 0 || b.Footer > 100 {
+		return fmt.Errorf("band heights %.1f/%.1f mm out of range (0-100)", b.Header, b.Footer)
+	}
+	if b.Color != "" {
+		if _, err := ParseColor(b.Color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Points returns the header and footer heights in points
+func (b Bands) Points() (header, footer float64) {
+	return b.Header * 72 / 25.4, b.Footer * 72 / 25.4
+}
+
+// Area returns the top and height, in points, of the part of the extended
+// page a stamp at p is placed in, for a page image imageH points high
+func (b Bands) Area(p Placement, imageH float64) (top, height float64) {
+	header, footer := b.Points()
+	switch p.Anchor {
+	case AnchorTopLeft, AnchorTopCenter, AnchorTopRight:
+		if header > 0 {
+			return 0, header
+		}
+	case AnchorMiddleLeft, AnchorCenter, AnchorMiddleRight:
+	default: // Bottom, including the empty anchor
+		if footer > 0 {
+			return header + imageH, footer
+		}
+	}
+	return header, imageH
+}
@@ -0,0 +1,34 @@
+package stamp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBandsArea(t *testing.T) {
+	const mm = 72 / 25.4
+	b := Bands{Header: 10, Footer: 20}
+	tests := []struct {
+		anchor         string
+		wantTop, wantH float64
+	}{
+		{AnchorTopLeft, 0, 10 * mm},
+		{AnchorCenter, 10 * mm, 800},
+		{AnchorBottomCenter, 10*mm + 800, 20 * mm},
+		{"", 10*mm + 800, 20 * mm},
+	}
+	for _, tt := range tests {
+		top, h := b.Area(Placement{Anchor: tt.anchor}, 800)
+		if math.Abs(top-tt.wantTop) > 1e-9 || math.Abs(h-tt.wantH) > 1e-9 {
+			t.Errorf("%q: got (%.2f, %.2f), want (%.2f, %.2f)", tt.anchor, top, h, tt.wantTop, tt.wantH)
+		}
+	}
+
+	// Without a header band, top stamps stay on the image
+	if top, h := (Bands{Footer: 20}).Area(Placement{Anchor: AnchorTopRight}, 800); top != 0 || h != 800 {
+		t.Errorf("Top stamp without header: got (%.2f, %.2f)", top, h)
+	}
+	if err := (Bands{Header: 150}).Validate(); err == nil {
+		t.Error("Header above 100 mm accepted")
+	}
+}
@@ -0,0 +1,83 @@
+package stamp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bates modes
+const (
+	BatesDocument = "document" // Serial plus page number, e.g. AR0042-0001
+	BatesGlobal   = "global"   // Running page numbers across documents
+)
+
+// Bates numbers every page of a document. In document mode the number is
+// the serial followed by the page, starting at Start in each document. In
+// global mode pages take consecutive numbers from a counter series of
+// their own, so numbering runs on across documents and batches.
+type Bates struct {
+	Mode      string    `json:"mode"`   // document or global; empty for none
+	Prefix    string    `json:"prefix"` // Global mode: text before the number, also names the counter series
+	Start     int       `json:"start"`  // First number; default 1
+	Pad       int       `json:"pad"`    // Digits; default 4 in document and 6 in global mode
+	Placement Placement `json:"placement"`
+	Style     Style     `json:"style"`
+}
+
+// Enabled reports whether pages get Bates numbers
+func (b Bates) Enabled() bool {
+	return b.Mode != ""
+}
+
+// Resolved returns b with defaults filled in for zero values
+func (b Bates) Resolved() Bates {
+	if b.Start == 0 {
+		b.Start = 1
+	}
+	if b.Pad == 0 {
+		b.Pad = 4
+		if b.Mode == BatesGlobal {
+			b.Pad = 6
+		}
+	}
+	return b
+}
+
+// Validate checks the mode, numbers, placement and style
+func (b Bates) Validate() error {
+	switch b.Mode {
+	case "", BatesDocument, BatesGlobal:
+	default:
+		return fmt.Errorf("unknown Bates mode %q (document or global)", b.Mode)
+	}
+	switch {
+	case b.Start < 0:
+		return fmt.Errorf("Bates start must be >= 1")
+	case b.Pad < 0 || b.Pad > 12:
+		return fmt.Errorf("Bates padding %d out of range (1-12)", b.Pad)
+	case strings.ContainsAny(b.Prefix, "{}"):
+		return fmt.Errorf("Bates prefix must not contain braces")
+	}
+	if err := b.Placement.Validate(); err != nil {
+		return fmt.Errorf("invalid Bates placement: %w", err)
+	}
+	if err := b.Style.Validate(); err != nil {
+		return fmt.Errorf("invalid Bates style: %w", err)
+	}
+	return nil
+}
+
+// Series is the counter series global Bates numbers are taken from
+func (b Bates) Series() string {
+	return "bates:" + strings.TrimSpace(b.Prefix)
+}
+
+// Number formats Bates number n: the padded page number after the serial
+// in document mode, after the prefix in global mode
+func (b Bates) Number(serial string, n int) string {
+	b = b.Resolved()
+	if b.Mode == BatesGlobal {
+		return fmt.Sprintf("%s%0*d", b.Prefix, b.Pad, n)
+	}
+	return fmt.Sprintf("%s-%0*d", serial, b.Pad, n)
+}
@@ -0,0 +1,25 @@
+package stamp
+
+import "testing"
+
+func TestBatesNumber(t *testing.T) {
+	doc := Bates{Mode: BatesDocument}
+	if got := doc.Number("AR0042", 17); got != "AR0042-0017" {
+		t.Errorf("Document Bates = %q, want AR0042-0017", got)
+	}
+	global := Bates{Mode: BatesGlobal, Prefix: "ACME"}
+	if got := global.Number("AR0042", 1234); got != "ACME001234" {
+		t.Errorf("Global Bates = %q, want ACME001234", got)
+	}
+	if err := (Bates{Mode: "pages"}).Validate(); err == nil {
+		t.Error("Unknown mode accepted")
+	}
+
+	tmpl, err := Parse("{bates} · {serial}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tmpl.Render(Fields{Serial: "AR0042", Bates: "AR0042-0003"}); got != "AR0042-0003 · AR0042" {
+		t.Errorf("Render = %q", got)
+	}
+}
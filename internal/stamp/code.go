@@ -0,0 +1,70 @@
+package stamp
+
+import "fmt"
+
+// Code types
+const (
+	CodeQR      = "qr"
+	CodeCode128 = "code128"
+)
+
+// Code defaults, in mm
+const (
+	DefaultQRSize      = 20.0
+	DefaultBarHeight   = 10.0
+	DefaultModuleWidth = 0.33
+)
+
+// Code is a machine-readable stamp. A layer with a code type draws a QR
+// code or Code 128 barcode encoding its rendered template, e.g. "{serial}",
+// "{serial} {hash8}" or "https://docs.example.com/verify/{serial}".
+// {hash8} and {hash} are the SHA-256 of the input file: the output cannot
+// carry its own hash. Code 128 encodes ASCII only.
+type Code struct {
+	Type   string  `json:"type"`   // qr or code128; empty for none
+	Size   float64 `json:"size"`   // mm; QR side or bar height, default 20 or 10
+	Module float64 `json:"module"` // Code 128 narrowest bar in mm; default 0.33
+}
+
+// Enabled reports whether the layer draws a code
+func (c Code) Enabled() bool {
+	return c.Type != ""
+}
+
+// Resolved returns c with defaults filled in for zero values
+func (c Code) Resolved() Code {
+	if c.Size == 0 {
+		c.Size = DefaultBarHeight
+		if c.Type == CodeQR {
+			c.Size = DefaultQRSize
+		}
+	}
+	if c.Module == 0 {
+		c.Module = DefaultModuleWidth
+	}
+	return c
+}
+
+// Validate checks the type and sizes
+func (c Code) Validate() error {
+	switch c.Type {
+	case "", CodeQR, CodeCode128:
+	default:
+		return fmt.Errorf("unknown code type %q (qr or code128)", c.Type)
+	}
+	switch {
+	case c.Size < 0 || c.Size > 500:
+		return fmt.Errorf("code size %.1f mm out of range (0-500)", c.Size)
+	case c.Module < 0 || c.Module > 5:
+		return fmt.Errorf("code module %.2f mm out of range (0-5)", c.Module)
+	}
+	return nil
+}
+
+// QuietZone is the blank margin a code needs around it, in modules
+func (c Code) QuietZone() int {
+	if c.Type == CodeQR {
+		return 4
+	}
+	return 10
+}
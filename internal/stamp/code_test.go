@@ -0,0 +1,19 @@
+package stamp
+
+import "testing"
+
+func TestCodeResolvedAndValidate(t *testing.T) {
+	if got := (Code{Type: CodeQR}).Resolved(); got.Size != DefaultQRSize {
+		t.Errorf("QR size = %.1f, want %.1f", got.Size, DefaultQRSize)
+	}
+	if got := (Code{Type: CodeCode128}).Resolved(); got.Size != DefaultBarHeight || got.Module != DefaultModuleWidth {
+		t.Errorf("Unexpected Code 128 defaults: %+v", got)
+	}
+	if err := (Code{Type: "ean13"}).Validate(); err == nil {
+		t.Error("Unknown code type accepted")
+	}
+	l := Layer{Image: Image{File: "seal.png"}, Code: Code{Type: CodeQR}}
+	if err := l.Validate(); err == nil {
+		t.Error("Layer with both an image and a code accepted")
+	}
+}
@@ -0,0 +1,50 @@
+package stamp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Fonts are TrueType files (.ttf, or .otf with TrueType outlines) in the
+// font folder of the config dir, referenced by file name from a style or
+// watermark. Characters a font has no glyph for are drawn in the first
+// font of the fallback list that has one, then in the built-in font.
+
+// IsFontFile reports whether name has a font file extension
+func IsFontFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ttf", ".otf":
+		return true
+	}
+	return false
+}
+
+// validFont checks a font reference; empty means the built-in font
+func validFont(name string) error {
+	if name == "" {
+		return nil
+	}
+	if !isFileName(name) || !IsFontFile(name) {
+		return fmt.Errorf("font %q must be a .ttf or .otf file name in the font folder", name)
+	}
+	return nil
+}
+
+// ValidateFallback checks a font fallback list
+func ValidateFallback(fonts []string) error {
+	seen := make(map[string]bool)
+	for _, f := range fonts {
+		if f == "" {
+			return fmt.Errorf("empty font name in the fallback list")
+		}
+		if err := validFont(f); err != nil {
+			return err
+		}
+		if seen[f] {
+			return fmt.Errorf("font %q is listed twice in the fallback list", f)
+		}
+		seen[f] = true
+	}
+	return nil
+}
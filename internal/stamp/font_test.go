@@ -0,0 +1,24 @@
+package stamp
+
+import "testing"
+
+func TestFontReferences(t *testing.T) {
+	if err := (Style{Font: "NotoSans-Regular.ttf"}).Validate(); err != nil {
+		t.Errorf("Valid font rejected: %v", err)
+	}
+	for _, bad := range []string{"../NotoSans.ttf", "NotoSans.woff2", "fonts/NotoSans.otf"} {
+		if err := (Style{Font: bad}).Validate(); err == nil {
+			t.Errorf("Font %q accepted", bad)
+		}
+	}
+	if err := (Watermark{Text: "COPY", Font: "x.exe"}).Validate(); err == nil {
+		t.Error("Watermark font without a font extension accepted")
+	}
+
+	if err := ValidateFallback([]string{"NotoSans.ttf", "NotoSansJP.otf"}); err != nil {
+		t.Errorf("Valid fallback list rejected: %v", err)
+	}
+	if err := ValidateFallback([]string{"NotoSans.ttf", "NotoSans.ttf"}); err == nil {
+		t.Error("Duplicate fallback font accepted")
+	}
+}
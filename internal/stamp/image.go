@@ -0,0 +1,55 @@
+package stamp
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Image is a picture stamp such as a company seal. The file is a PNG, which
+// may have an alpha channel, or a JPEG in the stamp image folder of the
+// config dir. A layer with an image draws it instead of its text.
+type Image struct {
+	File   string  `json:"file"`   // File name in the stamp image folder
+	Width  float64 `json:"width"`  // mm; 0 follows Height and the aspect ratio
+	Height float64 `json:"height"` // mm; 0 follows Width
+}
+
+// DefaultImageWidth is the width in mm of an image stamp without a size
+const DefaultImageWidth = 20.0
+
+// Enabled reports whether the layer draws an image
+func (i Image) Enabled() bool {
+	return i.File != ""
+}
+
+// Validate checks the file name and size
+func (i Image) Validate() error {
+	if !isFileName(i.File) {
+		return fmt.Errorf("image %q must be a file name in the stamp image folder", i.File)
+	}
+	if i.Width < 0 || i.Height < 0 || i.Width > 1000 || i.Height > 1000 {
+		return fmt.Errorf("image size %.1f×%.1f mm out of range (0-1000)", i.Width, i.Height)
+	}
+	return nil
+}
+
+// Size returns the drawn size in points of an image of pxW×pxH pixels,
+// keeping its aspect ratio unless both width and height are set
+func (i Image) Size(pxW, pxH int) (w, h float64) {
+	width, height := i.Width, i.Height
+	switch {
+	case width == 0 && height == 0:
+		width = DefaultImageWidth
+		fallthrough
+	case height == 0:
+		height = width * float64(pxH) / float64(pxW)
+	case width == 0:
+		width = height * float64(pxW) / float64(pxH)
+	}
+	return width * 72 / 25.4, height * 72 / 25.4
+}
+
+// isFileName reports whether name is a bare file name, without a directory
+func isFileName(name string) bool {
+	return name == filepath.Base(name) && name != "." && name != ".."
+}
@@ -0,0 +1,72 @@
+package stamp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layer is one stamp drawn on the page: its text, image or code, the pages
+// that carry it, where it goes and how it looks. Image stamps use only the
+// opacity and rotation of the style, codes also its color.
+type Layer struct {
+	Template  string    `json:"template"` // Empty uses DefaultTemplate
+	Image     Image     `json:"image"`    // Drawn instead of the text if set
+	Code      Code      `json:"code"`     // Encodes the text instead of showing it if set
+	Pages     string    `json:"pages"`    // See ParsePages
+	Placement Placement `json:"placement"`
+	Style     Style     `json:"style"`
+}
+
+// Validate checks the template or image, page scope, placement and style
+func (l Layer) Validate() error {
+	if l.Image.Enabled() && l.Code.Enabled() {
+		return fmt.Errorf("a layer has either an image or a code")
+	}
+	if l.Image.Enabled() {
+		if err := l.Image.Validate(); err != nil {
+			return err
+		}
+	} else if _, err := Parse(l.Template); err != nil {
+		return err
+	}
+	if err := l.Code.Validate(); err != nil {
+		return err
+	}
+	if _, err := ParsePages(l.Pages); err != nil {
+		return err
+	}
+	if err := l.Placement.Validate(); err != nil {
+		return fmt.Errorf("invalid placement: %w", err)
+	}
+	if err := l.Style.Validate(); err != nil {
+		return fmt.Errorf("invalid style: %w", err)
+	}
+	return nil
+}
+
+// Set is a named list of layers, drawn in order so later layers sit on top,
+// with an optional watermark underneath them
+type Set struct {
+	Name      string    `json:"name"`
+	Layers    []Layer   `json:"layers"`
+	Watermark Watermark `json:"watermark"`
+}
+
+// Validate checks the name and every layer
+func (s Set) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("stamp set name is required")
+	}
+	if len(s.Layers) == 0 && !s.Watermark.Enabled() {
+		return fmt.Errorf("stamp set %q has no layers", s.Name)
+	}
+	if err := s.Watermark.Validate(); err != nil {
+		return fmt.Errorf("stamp set %q: %w", s.Name, err)
+	}
+	for i, l := range s.Layers {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("stamp set %q, layer %d: %w", s.Name, i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package stamp
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSetValidate(t *testing.T) {
+	set := Set{Name: "copy", Layers: []Layer{
+		{Template: "{serial}", Placement: Placement{Anchor: AnchorTopRight}},
+		{Template: "COPY", Pages: PagesAll, Placement: Placement{Anchor: AnchorTopLeft}},
+		{Template: "{date}", Placement: Placement{Anchor: AnchorBottomCenter}, Style: Style{Size: 8}},
+	}}
+	if err := set.Validate(); err != nil {
+		t.Fatalf("Valid set rejected: %v", err)
+	}
+
+	if err := (Set{Layers: set.Layers}).Validate(); err == nil {
+		t.Error("Set without a name accepted")
+	}
+	if err := (Set{Name: "empty"}).Validate(); err == nil {
+		t.Error("Set without layers accepted")
+	}
+
+	bad := Set{Name: "bad", Layers: append(append([]Layer(nil), set.Layers...), Layer{Pages: "2-1"})}
+	err := bad.Validate()
+	if err == nil || !strings.Contains(err.Error(), "layer 4") {
+		t.Errorf("Expected an error naming layer 4, got %v", err)
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	const mm = 72 / 25.4
+	tests := []struct {
+		img          Image
+		wantW, wantH float64
+	}{
+		{Image{File: "seal.png"}, 20 * mm, 10 * mm},
+		{Image{File: "seal.png", Height: 30}, 60 * mm, 30 * mm},
+		{Image{File: "seal.png", Width: 10, Height: 10}, 10 * mm, 10 * mm},
+	}
+	for _, tt := range tests {
+		w, h := tt.img.Size(400, 200)
+		if math.Abs(w-tt.wantW) > 1e-9 || math.Abs(h-tt.wantH) > 1e-9 {
+			t.Errorf("%+v: got %.2f×%.2f, want %.2f×%.2f", tt.img, w, h, tt.wantW, tt.wantH)
+		}
+	}
+	if err := (Layer{Image: Image{File: "../seal.png"}}).Validate(); err == nil {
+		t.Error("Image path outside the stamp folder accepted")
+	}
+}
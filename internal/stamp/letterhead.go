@@ -0,0 +1,92 @@
+package stamp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Letterhead layers
+const (
+	LetterheadUnder = "under" // Beneath the page image, showing through its white
+	LetterheadOver  = "over"  // Above the page image
+)
+
+// Letterhead scaling rules for a template page of another size
+const (
+	ScaleFit     = "fit"     // Keep the aspect ratio, fit inside the page, centered
+	ScaleStretch = "stretch" // Stretch to the page size
+	ScaleNone    = "none"    // Actual size from the top-left corner
+)
+
+// Letterhead is a page of a template PDF, such as the company letterhead,
+// drawn as vector graphics with the page image. Under the image, the image
+// is multiplied onto it, so its white background lets the letterhead
+// show through while the content stays on top.
+type Letterhead struct {
+	File  string `json:"file"`  // PDF in the stamp image folder; empty for none
+	Page  int    `json:"page"`  // Template page; default 1
+	Layer string `json:"layer"` // under or over; default under
+	Scale string `json:"scale"` // fit, stretch or none; default fit
+	Pages string `json:"pages"` // See ParsePages; default all
+}
+
+// Enabled reports whether a letterhead is drawn
+func (l Letterhead) Enabled() bool {
+	return l.File != ""
+}
+
+// Resolved returns l with defaults filled in for zero values
+func (l Letterhead) Resolved() Letterhead {
+	if l.Page == 0 {
+		l.Page = 1
+	}
+	if l.Layer == "" {
+		l.Layer = LetterheadUnder
+	}
+	if l.Scale == "" {
+		l.Scale = ScaleFit
+	}
+	if l.Pages == "" {
+		l.Pages = PagesAll
+	}
+	return l
+}
+
+// Validate checks the file name, page, layer, scaling and page scope
+func (l Letterhead) Validate() error {
+	if l.File != "" && (!isFileName(l.File) || !strings.EqualFold(filepath.Ext(l.File), ".pdf")) {
+		return fmt.Errorf("letterhead %q must be a PDF file name in the stamp image folder", l.File)
+	}
+	if l.Page < 0 {
+		return fmt.Errorf("letterhead page must be >= 1")
+	}
+	switch l.Layer {
+	case "", LetterheadUnder, LetterheadOver:
+	default:
+		return fmt.Errorf("unknown letterhead layer %q (under or over)", l.Layer)
+	}
+	switch l.Scale {
+	case "", ScaleFit, ScaleStretch, ScaleNone:
+	default:
+		return fmt.Errorf("unknown letterhead scaling %q (fit, stretch or none)", l.Scale)
+	}
+	if _, err := ParsePages(l.Pages); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Frame returns where a tplW×tplH template page is drawn on a pageW×pageH
+// page: its upper-left corner and size, in points
+func (l Letterhead) Frame(tplW, tplH, pageW, pageH float64) (x, y, w, h float64) {
+	switch l.Resolved().Scale {
+	case ScaleStretch:
+		return 0, 0, pageW, pageH
+	case ScaleNone:
+		return 0, 0, tplW, tplH
+	}
+	s := min(pageW/tplW, pageH/tplH)
+	w, h = tplW*s, tplH*s
+	return (pageW - w) / 2, (pageH - h) / 2, w, h
+}
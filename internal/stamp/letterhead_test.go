@@ -0,0 +1,37 @@
+package stamp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLetterheadFrame(t *testing.T) {
+	const tplW, tplH = 595.0, 842.0 // A4 letterhead
+	tests := []struct {
+		scale        string
+		pageW, pageH float64
+		x, y, w, h   float64
+	}{
+		{ScaleFit, 595, 842, 0, 0, 595, 842},
+		{ScaleFit, 612, 792, (612 - 595.0*792/842) / 2, 0, 595.0 * 792 / 842, 792}, // US Letter
+		{ScaleStretch, 612, 792, 0, 0, 612, 792},
+		{ScaleNone, 612, 792, 0, 0, 595, 842},
+	}
+	for _, tt := range tests {
+		x, y, w, h := Letterhead{File: "lh.pdf", Scale: tt.scale}.Frame(tplW, tplH, tt.pageW, tt.pageH)
+		got, want := []float64{x, y, w, h}, []float64{tt.x, tt.y, tt.w, tt.h}
+		for i := range got {
+			if math.Abs(got[i]-want[i]) > 1e-9 {
+				t.Errorf("%s on %.0f×%.0f: got %.2f, want %.2f", tt.scale, tt.pageW, tt.pageH, got, want)
+				break
+			}
+		}
+	}
+
+	if err := (Letterhead{File: "letterhead.png"}).Validate(); err == nil {
+		t.Error("Non-PDF letterhead accepted")
+	}
+	if err := (Letterhead{File: "lh.pdf", Layer: "behind"}).Validate(); err == nil {
+		t.Error("Unknown layer accepted")
+	}
+}
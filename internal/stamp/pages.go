@@ -0,0 +1,100 @@
+package stamp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Page scopes
+const (
+	PagesFirst = "first"
+	PagesLast  = "last"
+	PagesAll   = "all"
+	PagesOdd   = "odd"
+	PagesEven  = "even"
+)
+
+// PageScope selects the pages of a document that carry a stamp: one of the
+// Pages* keywords or a comma-separated list of page numbers and ranges such
+// as "1-3,7,10-" ("10-" runs to the last page).
+type PageScope struct {
+	spec   string
+	ranges []pageRange
+}
+
+// pageRange is an inclusive range of pages; last 0 means the last page
+type pageRange struct {
+	first, last int
+}
+
+// ParsePages validates a page scope; "" is the same as PagesFirst
+func ParsePages(spec string) (PageScope, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	switch spec {
+	case "":
+		return PageScope{spec: PagesFirst}, nil
+	case PagesFirst, PagesLast, PagesAll, PagesOdd, PagesEven:
+		return PageScope{spec: spec}, nil
+	}
+
+	s := PageScope{spec: spec}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		lo, hi, isRange := strings.Cut(item, "-")
+		first, err := parsePage(lo)
+		if err != nil {
+			return PageScope{}, fmt.Errorf("invalid page scope %q: %w", spec, err)
+		}
+		r := pageRange{first: first, last: first}
+		if isRange {
+			r.last = 0
+			if hi = strings.TrimSpace(hi); hi != "" {
+				if r.last, err = parsePage(hi); err != nil {
+					return PageScope{}, fmt.Errorf("invalid page scope %q: %w", spec, err)
+				}
+				if r.last < r.first {
+					return PageScope{}, fmt.Errorf("invalid page scope %q: range %s is reversed", spec, item)
+				}
+			}
+		}
+		s.ranges = append(s.ranges, r)
+	}
+	return s, nil
+}
+
+func parsePage(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%q is not a page number", s)
+	}
+	return n, nil
+}
+
+// String returns the normalized scope
+func (s PageScope) String() string {
+	return s.spec
+}
+
+// Includes reports whether page (1-based) of a document with pages pages
+// is in the scope
+func (s PageScope) Includes(page, pages int) bool {
+	switch s.spec {
+	case PagesFirst:
+		return page == 1
+	case PagesLast:
+		return page == pages
+	case PagesAll:
+		return true
+	case PagesOdd:
+		return page%2 == 1
+	case PagesEven:
+		return page%2 == 0
+	}
+	for _, r := range s.ranges {
+		if page >= r.first && (r.last == 0 || page <= r.last) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package stamp
+
+import "testing"
+
+func TestPageScope(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // Stamped pages of a 7-page document
+	}{
+		{"", "1"},
+		{PagesFirst, "1"},
+		{PagesLast, "7"},
+		{PagesAll, "1234567"},
+		{PagesOdd, "1357"},
+		{"Even", "246"},
+		{"1-3, 6", "1236"},
+		{"5-", "567"},
+		{"2,9", "2"},
+	}
+	for _, tt := range tests {
+		s, err := ParsePages(tt.spec)
+		if err != nil {
+			t.Fatalf("ParsePages(%q) failed: %v", tt.spec, err)
+		}
+		got := ""
+		for p := 1; p <= 7; p++ {
+			if s.Includes(p, 7) {
+				got += string(rune('0' + p))
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%q stamps pages %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParsePagesRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"0", "3-1", "a-b", "1,,2", "-3", "middle"} {
+		if _, err := ParsePages(spec); err == nil {
+			t.Errorf("ParsePages(%q) should fail", spec)
+		}
+	}
+}
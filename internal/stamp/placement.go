@@ -0,0 +1,86 @@
+package stamp
+
+import "fmt"
+
+// Anchors of the 9-point placement grid
+const (
+	AnchorTopLeft      = "top-left"
+	AnchorTopCenter    = "top-center"
+	AnchorTopRight     = "top-right"
+	AnchorMiddleLeft   = "middle-left"
+	AnchorCenter       = "center"
+	AnchorMiddleRight  = "middle-right"
+	AnchorBottomLeft   = "bottom-left"
+	AnchorBottomCenter = "bottom-center"
+	AnchorBottomRight  = "bottom-right"
+)
+
+// Offset units
+const (
+	UnitPt = "pt"
+	UnitMM = "mm"
+)
+
+// Placement positions a stamp on the page as seen by the reader, so "top"
+// is the top of a landscape page as displayed.
+//
+// OffsetX is the distance from the left or right page edge the stamp is
+// anchored to, and OffsetY from the top or bottom edge; positive values move
+// the stamp inward. For the center column and middle row they shift the
+// stamp right and down.
+type Placement struct {
+	Anchor  string  `json:"anchor"`
+	OffsetX float64 `json:"offset_x"`
+	OffsetY float64 `json:"offset_y"`
+	Unit    string  `json:"unit"` // pt (default) or mm
+}
+
+// Validate checks the anchor and unit
+func (p Placement) Validate() error {
+	switch p.Anchor {
+	case "", AnchorTopLeft, AnchorTopCenter, AnchorTopRight,
+		AnchorMiddleLeft, AnchorCenter, AnchorMiddleRight,
+		AnchorBottomLeft, AnchorBottomCenter, AnchorBottomRight:
+	default:
+		return fmt.Errorf("unknown anchor %q", p.Anchor)
+	}
+	switch p.Unit {
+	case "", UnitPt, UnitMM:
+	default:
+		return fmt.Errorf("unknown unit %q (pt or mm)", p.Unit)
+	}
+	return nil
+}
+
+// points converts v from the placement's unit to points
+func (p Placement) points(v float64) float64 {
+	if p.Unit == UnitMM {
+		return v * 72 / 25.4
+	}
+	return v
+}
+
+// Place returns the upper-left corner, in points, of a w×h block on a
+// pageW×pageH page. An empty anchor is bottom-right.
+func (p Placement) Place(w, h, pageW, pageH float64) (x, y float64) {
+	dx, dy := p.points(p.OffsetX), p.points(p.OffsetY)
+
+	switch p.Anchor {
+	case AnchorTopLeft, AnchorMiddleLeft, AnchorBottomLeft:
+		x = dx
+	case AnchorTopCenter, AnchorCenter, AnchorBottomCenter:
+		x = (pageW-w)/2 + dx
+	default:
+		x = pageW - w - dx
+	}
+
+	switch p.Anchor {
+	case AnchorTopLeft, AnchorTopCenter, AnchorTopRight:
+		y = dy
+	case AnchorMiddleLeft, AnchorCenter, AnchorMiddleRight:
+		y = (pageH-h)/2 + dy
+	default:
+		y = pageH - h - dy
+	}
+	return x, y
+}
@@ -0,0 +1,36 @@
+package stamp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPlace(t *testing.T) {
+	const pageW, pageH, w, h = 600.0, 800.0, 100.0, 20.0
+	tests := []struct {
+		p            Placement
+		wantX, wantY float64
+	}{
+		{Placement{Anchor: AnchorTopLeft}, 0, 0},
+		{Placement{Anchor: AnchorTopRight, OffsetX: 10, OffsetY: 5}, 490, 5},
+		{Placement{Anchor: AnchorCenter}, 250, 390},
+		{Placement{Anchor: AnchorMiddleLeft, OffsetX: 4, OffsetY: -10}, 4, 380},
+		{Placement{Anchor: AnchorBottomCenter, OffsetY: 10, Unit: UnitMM}, 250, 800 - 20 - 10*72/25.4},
+		{Placement{}, 500, 780}, // bottom-right
+	}
+	for _, tt := range tests {
+		x, y := tt.p.Place(w, h, pageW, pageH)
+		if math.Abs(x-tt.wantX) > 1e-9 || math.Abs(y-tt.wantY) > 1e-9 {
+			t.Errorf("%+v: got (%.2f, %.2f), want (%.2f, %.2f)", tt.p, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestPlacementValidate(t *testing.T) {
+	if err := (Placement{Anchor: "upper-left"}).Validate(); err == nil {
+		t.Error("Unknown anchor accepted")
+	}
+	if err := (Placement{Anchor: AnchorCenter, Unit: "in"}).Validate(); err == nil {
+		t.Error("Unknown unit accepted")
+	}
+}
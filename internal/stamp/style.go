@@ -0,0 +1,122 @@
+package stamp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style controls how a stamp is drawn. Zero values mean the default, so a
+// partial style from the config still renders.
+type Style struct {
+	Color       string  `json:"color"`        // Text color, "#RRGGBB" or a basic color name
+	Size        float64 `json:"size"`         // Font size in points; default 12
+	Bold        bool    `json:"bold"`         // Bold or regular weight
+	Font        string  `json:"font"`         // Font file in the font folder; empty for the built-in font
+	Opacity     float64 `json:"opacity"`      // 0.05-1; 0 means opaque
+	Rotation    float64 `json:"rotation"`     // Degrees counter-clockwise around the stamp center
+	BoxColor    string  `json:"box_color"`    // Background fill; empty for none
+	BorderColor string  `json:"border_color"` // Box border; empty for none
+	BorderWidth float64 `json:"border_width"` // Border width in points; default 0.75
+	Padding     float64 `json:"padding"`      // Space between text and box edge, points; default 3
+}
+
+// Style defaults
+const (
+	DefaultColor       = "#FF0000"
+	DefaultSize        = 12.0
+	DefaultBorderWidth = 0.75
+	DefaultPadding     = 3.0
+)
+
+// DefaultStyle is the classic red bold serial
+func DefaultStyle() Style {
+	return Style{Color: DefaultColor, Size: DefaultSize, Bold: true, Opacity: 1}
+}
+
+// HasBox reports whether a background box is drawn
+func (s Style) HasBox() bool {
+	return s.BoxColor != "" || s.BorderColor != ""
+}
+
+// Resolved returns s with defaults filled in for zero values
+func (s Style) Resolved() Style {
+	if s.Color == "" {
+		s.Color = DefaultColor
+	}
+	if s.Size == 0 {
+		s.Size = DefaultSize
+	}
+	if s.Opacity == 0 {
+		s.Opacity = 1
+	}
+	if s.BorderColor != "" && s.BorderWidth == 0 {
+		s.BorderWidth = DefaultBorderWidth
+	}
+	if s.HasBox() && s.Padding == 0 {
+		s.Padding = DefaultPadding
+	}
+	return s
+}
+
+// Validate checks the style values
+func (s Style) Validate() error {
+	for _, c := range []string{s.Color, s.BoxColor, s.BorderColor} {
+		if c == "" {
+			continue
+		}
+		if _, err := ParseColor(c); err != nil {
+			return err
+		}
+	}
+	if err := validFont(s.Font); err != nil {
+		return err
+	}
+	switch {
+	case s.Size < 0 || s.Size > 400:
+		return fmt.Errorf("font size %.1f out of range (1-400)", s.Size)
+	case s.Opacity < 0 || s.Opacity > 1 || (s.Opacity > 0 && s.Opacity < 0.05):
+		return fmt.Errorf("opacity %.2f out of range (0.05-1)", s.Opacity)
+	case s.BorderWidth < 0 || s.Padding < 0:
+		return fmt.Errorf("border width and padding must not be negative")
+	}
+	return nil
+}
+
+// RGB is a color in 8-bit channels
+type RGB struct {
+	R, G, B uint8
+}
+
+// namedColors are the color names accepted besides hex
+var namedColors = map[string]RGB{
+	"black":  {0, 0, 0},
+	"white":  {255, 255, 255},
+	"red":    {255, 0, 0},
+	"green":  {0, 128, 0},
+	"blue":   {0, 0, 255},
+	"yellow": {255, 255, 0},
+	"orange": {255, 165, 0},
+	"gray":   {128, 128, 128},
+	"grey":   {128, 128, 128},
+}
+
+// ParseColor parses "#RRGGBB", "#RGB" or a basic color name
+func ParseColor(s string) (RGB, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return RGB{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid color %q", s)
+	}
+	return RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
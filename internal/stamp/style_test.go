@@ -0,0 +1,42 @@
+package stamp
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RGB
+	}{
+		{"#FF0000", RGB{255, 0, 0}},
+		{"1a2b3c", RGB{0x1a, 0x2b, 0x3c}},
+		{"#fff", RGB{255, 255, 255}},
+		{" Gray ", RGB{128, 128, 128}},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if err != nil {
+			t.Fatalf("ParseColor(%q) failed: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "#12345", "#GGGGGG", "purplish"} {
+		if _, err := ParseColor(bad); err == nil {
+			t.Errorf("ParseColor(%q) should fail", bad)
+		}
+	}
+}
+
+func TestStyleResolvedAndValidate(t *testing.T) {
+	s := Style{BoxColor: "white"}.Resolved()
+	if s.Color != DefaultColor || s.Size != DefaultSize || s.Opacity != 1 || s.Padding != DefaultPadding {
+		t.Errorf("Unexpected defaults: %+v", s)
+	}
+	if err := (Style{Opacity: 1.5}).Validate(); err == nil {
+		t.Error("Opacity above 1 accepted")
+	}
+	if err := (Style{BorderColor: "nope"}).Validate(); err == nil {
+		t.Error("Invalid border color accepted")
+	}
+}
@@ -0,0 +1,173 @@
+// Package stamp renders the text stamped onto frozen documents.
+package stamp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTemplate stamps just the serial
+const DefaultTemplate = "{serial}"
+
+// Default layouts of the {date} and {time} tokens
+const (
+	DefaultDateLayout = "2006-01-02"
+	DefaultTimeLayout = "15:04"
+)
+
+// Template is a parsed overlay template.
+//
+// Supported tokens:
+//
+//	{serial}        the serial number of the document
+//	{date}          the freeze date; {date:02.01.2006} takes a Go time layout
+//	{time}          the freeze time; {time:15:04:05} takes a Go time layout
+//	{filename}      the input file name
+//	{page}          the current page number
+//	{pages}         the page count
+//	{operator}      the user who froze the document
+//	{hash8}         the first 8 hex digits of the input file's SHA-256
+//	{hash}          the input file's full SHA-256
+//	{bates}         the Bates number of the current page, see Bates
+//	{settings}      a summary of the freeze settings, for the audit sheet
+//
+// Everything outside braces is copied literally, e.g.
+// "{serial} · frozen {date} · p. {page}/{pages}".
+type Template struct {
+	template string
+	parts    []part
+}
+
+// part is either literal text or a token with an optional argument
+type part struct {
+	literal string
+	token   string
+	arg     string
+}
+
+// Fields are the values a Template is rendered with
+type Fields struct {
+	Serial   string
+	Time     time.Time
+	FileName string
+	Page     int
+	Pages    int
+	Operator string
+	Hash     string // Hex SHA-256 of the input file
+	Bates    string // Bates number of the current page
+	Settings string // Summary of the freeze settings
+}
+
+// Parse validates an overlay template
+func Parse(tmpl string) (Template, error) {
+	t := Template{template: tmpl}
+
+	rest := tmpl
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, part{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return Template{}, fmt.Errorf("unexpected '}' in overlay template %q", tmpl)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, part{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return Template{}, fmt.Errorf("unclosed '{' in overlay template %q", tmpl)
+		}
+		p, err := parseToken(rest[open+1 : open+end])
+		if err != nil {
+			return Template{}, fmt.Errorf("overlay template %q: %w", tmpl, err)
+		}
+		t.parts = append(t.parts, p)
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+func parseToken(tok string) (part, error) {
+	name, arg, hasArg := strings.Cut(tok, ":")
+	switch name {
+	case "date", "time":
+		if hasArg && arg == "" {
+			return part{}, fmt.Errorf("empty layout for {%s}", name)
+		}
+		return part{token: name, arg: arg}, nil
+	case "serial", "filename", "page", "pages", "operator", "hash8", "hash", "bates", "settings":
+		if hasArg {
+			return part{}, fmt.Errorf("token {%s} takes no argument", name)
+		}
+		return part{token: name}, nil
+	default:
+		return part{}, fmt.Errorf("unknown token {%s}", tok)
+	}
+}
+
+// String returns the template it was parsed from
+func (t Template) String() string {
+	return t.template
+}
+
+// Uses reports whether the template contains token, so costly fields such
+// as the file hash are only computed when needed
+func (t Template) Uses(token string) bool {
+	for _, p := range t.parts {
+		if p.token == token {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesHash reports whether the template needs the input file hash
+func (t Template) UsesHash() bool {
+	return t.Uses("hash8") || t.Uses("hash")
+}
+
+// Render builds the stamp text from f
+func (t Template) Render(f Fields) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		switch p.token {
+		case "":
+			b.WriteString(p.literal)
+		case "serial":
+			b.WriteString(f.Serial)
+		case "date":
+			b.WriteString(f.Time.Format(layoutOr(p.arg, DefaultDateLayout)))
+		case "time":
+			b.WriteString(f.Time.Format(layoutOr(p.arg, DefaultTimeLayout)))
+		case "filename":
+			b.WriteString(f.FileName)
+		case "page":
+			fmt.Fprintf(&b, "%d", f.Page)
+		case "pages":
+			fmt.Fprintf(&b, "%d", f.Pages)
+		case "operator":
+			b.WriteString(f.Operator)
+		case "hash8":
+			if len(f.Hash) >= 8 {
+				b.WriteString(f.Hash[:8])
+			}
+		case "hash":
+			b.WriteString(f.Hash)
+		case "bates":
+			b.WriteString(f.Bates)
+		case "settings":
+			b.WriteString(f.Settings)
+		}
+	}
+	return b.String()
+}
+
+func layoutOr(layout, def string) string {
+	if layout == "" {
+		return def
+	}
+	return layout
+}
@@ -0,0 +1,52 @@
+package stamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	f := Fields{
+		Serial:   "AR0042",
+		Time:     time.Date(2026, time.October, 16, 9, 5, 0, 0, time.UTC),
+		FileName: "invoice.pdf",
+		Page:     3,
+		Pages:    7,
+		Operator: "jdoe",
+		Hash:     "9f86d081884c7d659a2feaa0c55ad015",
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{DefaultTemplate, "AR0042"},
+		{"{serial} · frozen {date} · p. {page}/{pages}", "AR0042 · frozen 2026-10-16 · p. 3/7"},
+		{"{date:02.01.2006} {time} {time:15:04:05}", "16.10.2026 09:05 09:05:00"},
+		{"{filename} by {operator} #{hash8}", "invoice.pdf by jdoe #9f86d081"},
+		{"SHA-256 {hash}", "SHA-256 9f86d081884c7d659a2feaa0c55ad015"},
+		{"plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.tmpl)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.tmpl, err)
+		}
+		if got := tmpl.Render(f); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, tmpl := range []string{
+		"{serial",
+		"serial}",
+		"{nope}",
+		"{page:2}",
+		"{date:}",
+	} {
+		if _, err := Parse(tmpl); err == nil {
+			t.Errorf("Parse(%q) should fail", tmpl)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package stamp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Watermark is large, faint text across the page, such as "CONFIDENTIAL"
+// or "COPY". Its font size follows the page size. Zero values mean the
+// default; an empty Text means no watermark.
+type Watermark struct {
+	Text    string  `json:"text"`    // Template, e.g. "COPY" or "COPY {serial}"
+	Angle   float64 `json:"angle"`   // Degrees counter-clockwise; 0 is horizontal
+	Scale   float64 `json:"scale"`   // Share of the page the text spans, 0.1-1; default 0.8
+	Opacity float64 `json:"opacity"` // 0.05-1; default 0.15
+	Color   string  `json:"color"`   // "#RRGGBB" or a basic color name; default gray
+	Font    string  `json:"font"`    // Font file in the font folder; empty for the built-in bold font
+	Pages   string  `json:"pages"`   // See ParsePages; default all
+}
+
+// Watermark defaults
+const (
+	DefaultWatermarkAngle   = 45.0
+	DefaultWatermarkScale   = 0.8
+	DefaultWatermarkOpacity = 0.15
+	DefaultWatermarkColor   = "#808080"
+)
+
+// Enabled reports whether a watermark is drawn
+func (w Watermark) Enabled() bool {
+	return w.Text != ""
+}
+
+// Resolved returns w with defaults filled in for zero values
+func (w Watermark) Resolved() Watermark {
+	if w.Scale == 0 {
+		w.Scale = DefaultWatermarkScale
+	}
+	if w.Opacity == 0 {
+		w.Opacity = DefaultWatermarkOpacity
+	}
+	if w.Color == "" {
+		w.Color = DefaultWatermarkColor
+	}
+	if w.Pages == "" {
+		w.Pages = PagesAll
+	}
+	return w
+}
+
+// Validate checks the watermark values
+func (w Watermark) Validate() error {
+	if _, err := Parse(w.Text); err != nil {
+		return err
+	}
+	if _, err := ParsePages(w.Pages); err != nil {
+		return err
+	}
+	if w.Color != "" {
+		if _, err := ParseColor(w.Color); err != nil {
+			return err
+		}
+	}
+	if err := validFont(w.Font); err != nil {
+		return err
+	}
+	switch {
+	case w.Scale < 0 || w.Scale > 1 || (w.Scale > 0 && w.Scale < 0.1):
+		return fmt.Errorf("watermark scale %.2f out of range (0.1-1)", w.Scale)
+	case w.Opacity < 0 || w.Opacity > 1 || (w.Opacity > 0 && w.Opacity < 0.05):
+		return fmt.Errorf("watermark opacity %.2f out of range (0.05-1)", w.Opacity)
+	}
+	return nil
+}
+
+// FontSize returns the font size at which text, textW wide and textH high
+// at size 1, spans Scale of a pageW×pageH page once rotated by Angle
+func (w Watermark) FontSize(textW, textH, pageW, pageH float64) float64 {
+	rad := w.Angle * math.Pi / 180
+	cos, sin := math.Abs(math.Cos(rad)), math.Abs(math.Sin(rad))
+	// Bounding box of the rotated text at size 1
+	boxW := textW*cos + textH*sin
+	boxH := textW*sin + textH*cos
+	if boxW <= 0 || boxH <= 0 {
+		return 0
+	}
+	scale := w.Resolved().Scale
+	return math.Min(scale*pageW/boxW, scale*pageH/boxH)
+}
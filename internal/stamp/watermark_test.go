@@ -0,0 +1,42 @@
+package stamp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWatermarkFontSize(t *testing.T) {
+	// Horizontal text limited by the page width
+	w := Watermark{Text: "COPY", Scale: 0.5}
+	if got := w.FontSize(2, 1, 600, 800); math.Abs(got-150) > 1e-9 {
+		t.Errorf("Horizontal size = %.2f, want 150", got)
+	}
+
+	// At 90° the text runs along the page height
+	w.Angle = 90
+	if got := w.FontSize(2, 1, 600, 800); math.Abs(got-200) > 1e-9 {
+		t.Errorf("Vertical size = %.2f, want 200", got)
+	}
+
+	// A diagonal watermark fits within the page both ways
+	w = Watermark{Text: "CONFIDENTIAL", Angle: DefaultWatermarkAngle}
+	size := w.FontSize(7, 1, 595, 842)
+	rad := w.Angle * math.Pi / 180
+	boxW := size * (7*math.Cos(rad) + math.Sin(rad))
+	boxH := size * (7*math.Sin(rad) + math.Cos(rad))
+	if boxW > 0.8*595+1e-9 || boxH > 0.8*842+1e-9 {
+		t.Errorf("Diagonal watermark %.1f×%.1f overflows the page", boxW, boxH)
+	}
+}
+
+func TestWatermarkValidate(t *testing.T) {
+	if err := (Watermark{Text: "COPY", Scale: 1.5}).Validate(); err == nil {
+		t.Error("Scale above 1 accepted")
+	}
+	if err := (Watermark{Text: "COPY", Color: "nope"}).Validate(); err == nil {
+		t.Error("Invalid color accepted")
+	}
+	if got := (Watermark{}).Resolved(); got.Pages != PagesAll || got.Opacity != DefaultWatermarkOpacity {
+		t.Errorf("Unexpected defaults: %+v", got)
+	}
+}
@@ -1,15 +1,27 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 
+	"pdf-freezer/internal/atomicfile"
 	"pdf-freezer/internal/config"
 	"pdf-freezer/internal/counter"
 	"pdf-freezer/internal/engine"
+	"pdf-freezer/internal/serial"
+	"pdf-freezer/internal/stamp"
 )
 
 // App struct
@@ -18,6 +30,14 @@ type App struct {
 	config   *config.Manager
 	logger   *config.Logger
 	pipeline *engine.Pipeline
+
+	mu          sync.Mutex
+	cancelBatch context.CancelFunc // set while ProcessFiles runs
+
+	// Test mode issues numbers from a separate sandbox counter
+	testMode        bool
+	sandbox         *counter.Manager
+	sandboxPipeline *engine.Pipeline
 }
 
 // NewApp creates a new App application struct
@@ -30,19 +50,19 @@ func NewApp() *App {
 		l.Info("App starting...")
 	}
 
-	// Init Counter
-	c, err := counter.NewManager()
+	// Init Config
+	cfg, err := config.NewManager()
 	if err != nil {
 		if l != nil {
-			l.Error(fmt.Sprintf("Failed to init counter: %v", err))
+			l.Error(fmt.Sprintf("Failed to init config: %v", err))
 		}
 	}
 
-	// Init Config
-	cfg, err := config.NewManager()
+	// Init Counter
+	c, err := newCounter(cfg, l)
 	if err != nil {
 		if l != nil {
-			l.Error(fmt.Sprintf("Failed to init config: %v", err))
+			l.Error(fmt.Sprintf("Failed to init counter: %v", err))
 		}
 	}
 
@@ -57,6 +77,55 @@ func NewApp() *App {
 	}
 }
 
+// newCounter creates the counter manager on the storage backend selected in
+// the config
+func newCounter(cfg *config.Manager, l *config.Logger) (*counter.Manager, error) {
+	var c *counter.Manager
+	if cfg != nil {
+		storeCfg := cfg.Current.StoreConfig()
+		store, err := counter.OpenStore(storeCfg)
+		if err != nil {
+			return nil, err
+		}
+		c = counter.NewManagerWithStore(store)
+		if l != nil && storeCfg.Backend != "" && storeCfg.Backend != counter.BackendFile {
+			where := storeCfg.Location
+			if storeCfg.Backend == counter.BackendServer {
+				where = storeCfg.ServerURL
+			}
+			l.Info(fmt.Sprintf("Using %s counter backend %s", storeCfg.Backend, where))
+		}
+	} else {
+		var err error
+		c, err = counter.NewManager()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg != nil {
+		if err := c.SetResetPolicy(cfg.Current.ResetPolicy()); err != nil && l != nil {
+			l.Error(fmt.Sprintf("Invalid counter reset policy: %v", err))
+		}
+		// Record a PIN set before the ledger tracked it, or kept while
+		// switching backends, so deleting it cannot go unnoticed
+		if cfg.Current.AdminPINHash != "" {
+			if set, err := c.PINWasSet(); err == nil && !set {
+				_ = c.RecordPINChange()
+			}
+		}
+	}
+	if l != nil {
+		c.OnStaleLock = func(info counter.LockInfo) {
+			l.Info(fmt.Sprintf("Recovered stale counter lock held by %s", info))
+		}
+		c.OnReset = func(e counter.Event) {
+			l.Info(fmt.Sprintf("Counter %s reset for period %s (was %d)", e.Series, e.Period, e.Previous))
+		}
+	}
+	return c, nil
+}
+
 // CheckDeps checks if system dependencies (GS) are met
 func (a *App) CheckDeps() error {
 	wrapper := engine.NewGhostscriptWrapper()
@@ -106,16 +175,12 @@ func (a *App) SelectFile() (string, error) {
 	return file, nil
 }
 
-// ProcessFile freezes the PDF
-func (a *App) ProcessFile(inputPath string, overlayOverride bool, prefixOverride string, positionOverride string, suffixOverride string, overwriteMode bool, compressionLevel string) (string, error) {
-	if a.logger != nil {
-		a.logger.Info(fmt.Sprintf("Processing file: %s", inputPath))
-	}
-
+// buildOptions resolves the job settings for one file from the UI overrides
+// and the config
+func (a *App) buildOptions(inputPath string, overlayOverride bool, prefixOverride string, positionOverride string, suffixOverride string, overwriteMode bool, compressionLevel string) (engine.ProcessOptions, error) {
 	if inputPath == "" {
-		return "", fmt.Errorf("no input file selected")
+		return engine.ProcessOptions{}, fmt.Errorf("no input file selected")
 	}
-
 	dir := filepath.Dir(inputPath)
 	ext := filepath.Ext(inputPath)
 	base := filepath.Base(inputPath)
@@ -152,7 +217,12 @@ func (a *App) ProcessFile(inputPath string, overlayOverride bool, prefixOverride
 		position = a.config.Current.OverlayPosition
 	}
 	if position == "" {
-		position = "bottom-right"
+		position = stamp.AnchorBottomRight
+	}
+	placement := stamp.Placement{Anchor: position, OffsetX: 1, OffsetY: 1}
+	if a.config != nil {
+		placement = a.config.Current.Placement()
+		placement.Anchor = position
 	}
 
 	// Use provided compression or fallback to config
@@ -169,14 +239,78 @@ func (a *App) ProcessFile(inputPath string, overlayOverride bool, prefixOverride
 		OutputPath:       outputPath,
 		Overlay:          overlayOverride,
 		Prefix:           prefix,
-		Position:         position,
+		Series:           a.seriesFor(prefix),
+		SerialFormat:     a.serialFormat(),
+		CheckDigits:      a.checkDigits(),
+		Placement:        placement,
 		CompressionLevel: compression,
+		Operator:         a.operator(),
+	}
+	if a.config != nil {
+		opts.OverlayTemplate = a.config.Current.OverlayTemplate
+		opts.OverlayPages = a.config.Current.OverlayPages
+		opts.Style = a.config.Current.Style()
+		opts.Watermark = a.config.Current.Watermark
+		opts.Bates = a.config.Current.Bates
+		opts.Bands = a.config.Current.Bands
+		opts.Audit = a.config.Current.AuditSheet
+		opts.Letterhead = a.config.Current.Letterhead
+		opts.ImageDir = a.config.ImageDir()
+		opts.FontDir = a.config.FontDir()
+		opts.FontFallback = a.config.Current.FontFallback
+		if set, ok := a.config.Current.ActiveStampSet(); ok {
+			opts.Layers = set.Layers
+			opts.Watermark = set.Watermark
+			if len(set.Layers) == 0 {
+				// A watermark-only set; keep the single overlay off
+				opts.Overlay = false
+			}
+		} else if layers := a.config.Current.OverlayLayers(); len(layers) > 1 {
+			opts.Layers = layers
+			opts.Layers[0].Placement = placement
+		}
+	}
+
+	return opts, nil
+}
+
+// operator returns the name stamped by {operator}: the configured name or
+// the OS user
+func (a *App) operator() string {
+	if a.config != nil && a.config.Current.Operator != "" {
+		return a.config.Current.Operator
+	}
+	if u, err := user.Current(); err == nil {
+		// Drop the domain of Windows accounts (DOMAIN\user)
+		name := u.Username
+		if i := strings.LastIndexByte(name, '\\'); i >= 0 {
+			name = name[i+1:]
+		}
+		return name
+	}
+	return ""
+}
+
+// ProcessFile freezes the PDF
+func (a *App) ProcessFile(inputPath string, overlayOverride bool, prefixOverride string, positionOverride string, suffixOverride string, overwriteMode bool, compressionLevel string) (string, error) {
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Processing file: %s", inputPath))
+	}
+
+	_, pipeline, test := a.active()
+	opts, err := a.buildOptions(inputPath, overlayOverride, prefixOverride, positionOverride, suffixOverride, overwriteMode, compressionLevel)
+	if err != nil {
+		return "", err
+	}
+	if test {
+		a.applyTestMode(&opts)
 	}
+	outputPath := opts.OutputPath
 
 	// Use background context for pipeline
 	ctx := context.TODO()
 
-	err := a.pipeline.Process(ctx, opts)
+	err = pipeline.Process(ctx, opts)
 	if err != nil {
 		if a.logger != nil {
 			a.logger.Error(fmt.Sprintf("Process failed: %v", err))
@@ -190,38 +324,271 @@ func (a *App) ProcessFile(inputPath string, overlayOverride bool, prefixOverride
 	return outputPath, nil
 }
 
-// GetCurrentNumber returns the next number
+// seriesFor returns the counter series used for jobs with the given prefix
+func (a *App) seriesFor(prefix string) string {
+	if a.config != nil && a.config.Current.Series != "" {
+		return a.config.Current.Series
+	}
+	if prefix == "" && a.config != nil {
+		prefix = a.config.Current.Prefix
+	}
+	if prefix == "" {
+		prefix = "AR"
+	}
+	return prefix
+}
+
+// ProcessFiles freezes several PDFs as one batch. A contiguous block of
+// serials is reserved up front and assigned in file path order; numbers not
+// used because the batch was cancelled are returned to the counter.
+func (a *App) ProcessFiles(inputPaths []string, overlayOverride bool, prefixOverride string, positionOverride string, suffixOverride string, overwriteMode bool, compressionLevel string) ([]engine.BatchResult, error) {
+	if len(inputPaths) == 0 {
+		return nil, fmt.Errorf("no input files selected")
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Processing batch of %d files", len(inputPaths)))
+	}
+
+	_, pipeline, test := a.active()
+	jobs := make([]engine.ProcessOptions, 0, len(inputPaths))
+	for _, p := range inputPaths {
+		opts, err := a.buildOptions(p, overlayOverride, prefixOverride, positionOverride, suffixOverride, overwriteMode, compressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		if test {
+			a.applyTestMode(&opts)
+		}
+		jobs = append(jobs, opts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.mu.Lock()
+	if a.cancelBatch != nil {
+		a.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("a batch is already running")
+	}
+	a.cancelBatch = cancel
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.cancelBatch = nil
+		a.mu.Unlock()
+		cancel()
+	}()
+
+	results, err := pipeline.ProcessBatch(ctx, jobs)
+	if a.logger != nil {
+		for _, r := range results {
+			if r.Error != "" {
+				a.logger.Error(fmt.Sprintf("Batch file %s (%s) failed: %s", r.InputPath, r.Serial, r.Error))
+			} else {
+				a.logger.Info(fmt.Sprintf("Success: %s (%s)", r.OutputPath, r.Serial))
+			}
+		}
+		if err != nil {
+			a.logger.Error(fmt.Sprintf("Batch failed: %v", err))
+		}
+	}
+	return results, err
+}
+
+// CancelBatch stops the running batch after the current file
+func (a *App) CancelBatch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancelBatch != nil {
+		if a.logger != nil {
+			a.logger.Info("Batch cancelled")
+		}
+		a.cancelBatch()
+	}
+}
+
+// GetCurrentNumber returns the next number of the active series
 func (a *App) GetCurrentNumber() (int, error) {
-	if a.counter == nil {
+	c, _, _ := a.active()
+	if c == nil {
 		return 0, fmt.Errorf("counter not initialized")
 	}
-	val, err := a.counter.GetCurrent()
+	val, err := c.GetCurrent(a.seriesFor(""))
 	if err != nil {
 		return 0, err
 	}
 	return val + 1, nil
 }
 
-// SetNumberOverride sets the counter config
-func (a *App) SetNumberOverride(val int) error {
-	if a.counter == nil {
+// SetNumberOverride sets the next number of the active series.
+// It requires the admin PIN and a reason, both recorded with the change.
+// Going back below numbers already issued is refused unless force is set.
+func (a *App) SetNumberOverride(val int, reason, pin string, force bool) error {
+	c, _, _ := a.active()
+	if c == nil {
 		return fmt.Errorf("counter not initialized")
 	}
 	if val < 1 {
 		return fmt.Errorf("number must be >= 1")
 	}
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.CheckAdminPIN(pin); err != nil {
+		return err
+	}
+	series := a.seriesFor("")
+	err := c.SetOverride(series, counter.Override{Value: val - 1, Reason: reason, Force: force, PIN: pin})
+	if a.logger != nil {
+		if err != nil {
+			a.logger.Error(fmt.Sprintf("Counter override of %s to %d refused: %v", series, val, err))
+		} else {
+			a.logger.Info(fmt.Sprintf("Counter override of %s to %d (forced: %v): %s", series, val, force, reason))
+		}
+	}
+	return err
+}
+
+// AcknowledgeIntegrityAlert accepts a counter state that failed its
+// integrity check so numbers can be issued again. It requires the admin PIN
+// and a reason, which is recorded in the ledger.
+func (a *App) AcknowledgeIntegrityAlert(reason, pin string) error {
+	c, _, _ := a.active()
+	if c == nil {
+		return fmt.Errorf("counter not initialized")
+	}
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.CheckAdminPIN(pin); err != nil {
+		return err
+	}
+	if err := c.AcknowledgeTamper(reason); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Counter integrity alert acknowledged: %s", reason))
+	}
+	return nil
+}
+
+// SetAdminPIN sets or changes the admin PIN that protects counter overrides
+func (a *App) SetAdminPIN(currentPIN, newPIN string) error {
+	a.mu.Lock()
+	c := a.counter
+	a.mu.Unlock()
+	if a.config == nil || c == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	// Without a PIN in the config, setting one needs no current PIN; that
+	// is only allowed if none was ever set, not if it was deleted
+	if !a.HasAdminPIN() {
+		set, err := c.PINWasSet()
+		if err != nil {
+			return fmt.Errorf("cannot check the counter ledger: %w", err)
+		}
+		if set {
+			return fmt.Errorf("the admin PIN was removed from the config; restore admin_pin_hash from config.json.bak")
+		}
+	}
+	if err := a.config.UpdateAdminPIN(currentPIN, newPIN); err != nil {
+		return err
+	}
+	if err := c.RecordPINChange(); err != nil {
+		return fmt.Errorf("admin PIN changed but not recorded in the ledger: %w", err)
+	}
 	if a.logger != nil {
-		a.logger.Info(fmt.Sprintf("Counter override to: %d", val))
+		a.logger.Info("Admin PIN changed")
+	}
+	return nil
+}
+
+// HasAdminPIN reports whether an admin PIN has been configured
+func (a *App) HasAdminPIN() bool {
+	return a.config != nil && a.config.Current.AdminPINHash != ""
+}
+
+// serialFormat returns the configured serial format template
+func (a *App) serialFormat() string {
+	if a.config != nil && a.config.Current.SerialFormat != "" {
+		return a.config.Current.SerialFormat
+	}
+	return serial.DefaultFormat
+}
+
+// checkDigits returns the configured check digit scheme
+func (a *App) checkDigits() string {
+	if a.config != nil {
+		return a.config.Current.CheckDigits
+	}
+	return serial.CheckNone
+}
+
+// GetCurrentSerial previews the serial the next job will receive, rendered
+// with the configured format from the number GetCurrentNumber returns
+func (a *App) GetCurrentSerial() (string, error) {
+	n, err := a.GetCurrentNumber()
+	if err != nil {
+		return "", err
+	}
+	format, err := serial.Parse(a.serialFormat())
+	if err != nil {
+		return "", err
+	}
+	prefix := "AR"
+	if a.config != nil && a.config.Current.Prefix != "" {
+		prefix = a.config.Current.Prefix
+	}
+	check, err := serial.ParseCheck(a.checkDigits())
+	if err != nil {
+		return "", err
+	}
+	text := check.Append(format.Render(prefix, n, time.Now()))
+	if _, _, test := a.active(); test {
+		text = a.testMarker() + " " + text
+	}
+	return text, nil
+}
+
+// ValidateSerial checks the check digits of a typed serial with the
+// configured scheme
+func (a *App) ValidateSerial(s string) (bool, error) {
+	check, err := serial.ParseCheck(a.checkDigits())
+	if err != nil {
+		return false, err
+	}
+	if !check.Enabled() {
+		return false, fmt.Errorf("no check digit scheme configured")
+	}
+	return check.Valid(s), nil
+}
+
+// ListSeries returns the current value of every counter series
+func (a *App) ListSeries() (map[string]int, error) {
+	c, _, _ := a.active()
+	if c == nil {
+		return nil, fmt.Errorf("counter not initialized")
 	}
-	return a.counter.SetOverride(val - 1)
+	return c.ListSeries()
 }
 
-// GetConfig returns current config
+// GetCounterHistory returns the ledger events of a counter series
+func (a *App) GetCounterHistory(series string) ([]counter.Event, error) {
+	c, _, _ := a.active()
+	if c == nil {
+		return nil, fmt.Errorf("counter not initialized")
+	}
+	return c.History(series)
+}
+
+// GetConfig returns current config. The admin PIN hash and the counter
+// server token are left out; the frontend never needs them.
 func (a *App) GetConfig() config.AppConfig {
 	if a.config == nil {
 		return config.DefaultConfig()
 	}
-	return a.config.Current
+	cfg := a.config.Current
+	cfg.AdminPINHash, cfg.CounterToken = "", ""
+	return cfg
 }
 
 // SetPrefix updates the serial number prefix
@@ -235,6 +602,636 @@ func (a *App) SetPrefix(prefix string) error {
 	return a.config.UpdatePrefix(prefix)
 }
 
+// SetSeries selects the counter series; empty uses the prefix
+func (a *App) SetSeries(series string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Series updated to: %s", series))
+	}
+	return a.config.UpdateSeries(series)
+}
+
+// SetSerialFormat validates and updates the serial format template
+func (a *App) SetSerialFormat(format string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateSerialFormat(format); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Serial format updated to: %s", format))
+	}
+	return nil
+}
+
+// SetCheckDigits selects the check digit scheme appended to serials
+// (none, luhn, mod97-10, damm)
+func (a *App) SetCheckDigits(scheme string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateCheckDigits(scheme); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Check digit scheme updated to: %s", scheme))
+	}
+	return nil
+}
+
+// SetResetPolicy updates the automatic counter reset (never, yearly,
+// monthly) and the month a yearly period starts in
+func (a *App) SetResetPolicy(period string, fiscalYearStart int) error {
+	a.mu.Lock()
+	c, sandbox := a.counter, a.sandbox
+	a.mu.Unlock()
+	if a.config == nil || c == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateResetPolicy(period, fiscalYearStart); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Reset policy updated to: %s (fiscal year start %d)", period, fiscalYearStart))
+	}
+	policy := a.config.Current.ResetPolicy()
+	if sandbox != nil {
+		_ = sandbox.SetResetPolicy(policy)
+	}
+	return c.SetResetPolicy(policy)
+}
+
+// SetCounterServer switches to a shared counter server, or back to the local
+// counter file when url is empty
+func (a *App) SetCounterServer(url, token string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	prevURL, prevToken := a.config.Current.CounterServer, a.config.Current.CounterToken
+	if err := a.config.UpdateCounterServer(url, token); err != nil {
+		return err
+	}
+	backend, location := counter.BackendServer, ""
+	if url == "" {
+		backend = counter.BackendFile
+	}
+	if err := a.SetCounterStore(backend, location); err != nil {
+		_ = a.config.UpdateCounterServer(prevURL, prevToken)
+		return err
+	}
+	return nil
+}
+
+// SetCounterStore selects the counter storage backend (file, sqlite, share,
+// server) and its location, and switches to it. The series of the current
+// store are carried over first, so a new, empty store does not issue the
+// same numbers again; if that fails, the current store stays in use.
+func (a *App) SetCounterStore(backend, location string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	a.mu.Lock()
+	current, busy := a.counter, a.cancelBatch != nil
+	a.mu.Unlock()
+	if busy {
+		return fmt.Errorf("cannot switch the counter backend while a batch is running")
+	}
+	prev := a.config.Current.StoreConfig()
+	if err := a.config.UpdateCounterStore(backend, location); err != nil {
+		return err
+	}
+	c, err := newCounter(a.config, a.logger)
+	if err == nil && current != nil {
+		if err = c.Adopt(current, describeStore(prev)); err != nil {
+			_ = c.Close()
+		}
+	}
+	a.mu.Lock()
+	if err == nil && a.cancelBatch != nil {
+		// A batch started while the series were carried over
+		_ = c.Close()
+		err = fmt.Errorf("a batch is running")
+	}
+	if err != nil {
+		a.mu.Unlock()
+		_ = a.config.UpdateCounterStore(prev.Backend, prev.Location)
+		return fmt.Errorf("counter backend not switched: %w", err)
+	}
+	if a.counter != nil {
+		_ = a.counter.Close()
+	}
+	a.counter = c
+	a.pipeline = engine.NewPipeline(c)
+	a.mu.Unlock()
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Counter backend updated to: %s", backend))
+	}
+	return nil
+}
+
+// describeStore names a counter store for the ledger
+func describeStore(cfg counter.StoreConfig) string {
+	backend := cfg.Backend
+	if backend == "" {
+		backend = counter.BackendFile
+	}
+	switch {
+	case backend == counter.BackendServer:
+		return backend + " " + cfg.ServerURL
+	case cfg.Location != "":
+		return backend + " " + cfg.Location
+	}
+	return backend + " in the config dir"
+}
+
+// active returns the counter and pipeline jobs use and whether test mode
+// is on; in test mode they are the sandbox's
+func (a *App) active() (*counter.Manager, *engine.Pipeline, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.testMode {
+		return a.sandbox, a.sandboxPipeline, true
+	}
+	return a.counter, a.pipeline, false
+}
+
+// testMarker returns the configured test mode marker
+func (a *App) testMarker() string {
+	if a.config != nil && a.config.Current.TestMarker != "" {
+		return a.config.Current.TestMarker
+	}
+	return "TEST"
+}
+
+// applyTestMode stamps the test marker on a job and writes its output next
+// to the input with the test suffix, never over the original
+func (a *App) applyTestMode(opts *engine.ProcessOptions) {
+	suffix := "_test"
+	if a.config != nil && a.config.Current.TestSuffix != "" {
+		suffix = a.config.Current.TestSuffix
+	}
+	opts.Marker = a.testMarker()
+	opts.OutputPath = strings.TrimSuffix(opts.InputPath, filepath.Ext(opts.InputPath)) + suffix + ".pdf"
+}
+
+// SetTestMode switches test mode on or off. In test mode numbers come from
+// a sandbox counter, the output is stamped with the test marker and saved
+// with the test suffix; the production counter and ledger are not touched.
+func (a *App) SetTestMode(enabled bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancelBatch != nil {
+		return fmt.Errorf("cannot switch test mode while a batch is running")
+	}
+
+	if enabled && a.sandbox == nil {
+		c, err := counter.NewSandboxManager()
+		if err != nil {
+			return fmt.Errorf("failed to open sandbox counter: %w", err)
+		}
+		if a.config != nil {
+			_ = c.SetResetPolicy(a.config.Current.ResetPolicy())
+		}
+		a.sandbox = c
+		a.sandboxPipeline = engine.NewPipeline(c)
+	}
+	a.testMode = enabled
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Test mode enabled: %v", enabled))
+	}
+	return nil
+}
+
+// IsTestMode reports whether test mode is on
+func (a *App) IsTestMode() bool {
+	_, _, test := a.active()
+	return test
+}
+
+// SetTestOptions updates the test mode marker and output suffix
+func (a *App) SetTestOptions(marker, suffix string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateTestMode(marker, suffix); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Test mode marker %q, suffix %q", marker, suffix))
+	}
+	return nil
+}
+
+// SetOverlayTemplate validates and updates the overlay text template
+func (a *App) SetOverlayTemplate(tmpl string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateOverlayTemplate(tmpl); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Overlay template updated to: %s", tmpl))
+	}
+	return nil
+}
+
+// SetOverlayStyle updates the overlay color, size, weight, opacity,
+// rotation and background box
+func (a *App) SetOverlayStyle(style stamp.Style) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateOverlayStyle(style); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Overlay style updated to: %+v", style))
+	}
+	return nil
+}
+
+// SelectStampImage lets the user pick a PNG or JPEG and imports it as an
+// image stamp; it returns the file name to reference, or "" if cancelled
+func (a *App) SelectStampImage() (string, error) {
+	app := application.Get()
+
+	dialog := app.Dialog.OpenFile()
+	dialog.SetTitle("Select Stamp Image")
+	dialog.AddFilter("Images", "*.png;*.jpg;*.jpeg")
+	if currentWindow := app.Window.Current(); currentWindow != nil {
+		dialog.AttachToWindow(currentWindow)
+	}
+
+	file, err := dialog.PromptForSingleSelection()
+	if err != nil || file == "" {
+		return "", err
+	}
+	return a.ImportStampImage(file)
+}
+
+// ImportStampImage copies a PNG or JPEG into the stamp image folder of the
+// config dir and returns the file name to reference from an image stamp
+func (a *App) ImportStampImage(path string) (string, error) {
+	if a.config == nil {
+		return "", fmt.Errorf("config not initialized")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || (format != "png" && format != "jpeg") {
+		return "", fmt.Errorf("%s is not a PNG or JPEG image", filepath.Base(path))
+	}
+
+	name, err := importFile(a.config.ImageDir(), filepath.Base(path), data)
+	if err != nil {
+		return "", err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Stamp image imported: %s", name))
+	}
+	return name, nil
+}
+
+// importFile stores data in dir under name and returns the name it got. A
+// different file of that name, which stamps may already refer to, is kept
+// and the import gets a numbered name such as logo-2.png instead; importing
+// the same file again reuses it.
+func importFile(dir, name string, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		target := filepath.Join(dir, candidate)
+		existing, err := os.ReadFile(target)
+		if os.IsNotExist(err) {
+			return candidate, atomicfile.Replace(target, data, 0644)
+		}
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(existing, data) {
+			return candidate, nil
+		}
+	}
+}
+
+// SelectFont opens a file dialog and imports the chosen font, see
+// ImportFont
+func (a *App) SelectFont() (string, error) {
+	app := application.Get()
+
+	dialog := app.Dialog.OpenFile()
+	dialog.SetTitle("Select Font")
+	dialog.AddFilter("Fonts", "*.ttf;*.otf")
+	if currentWindow := app.Window.Current(); currentWindow != nil {
+		dialog.AttachToWindow(currentWindow)
+	}
+
+	file, err := dialog.PromptForSingleSelection()
+	if err != nil || file == "" {
+		return "", err
+	}
+	return a.ImportFont(file)
+}
+
+// ImportFont copies a TrueType font into the font folder of the config dir
+// and returns the file name to reference from a stamp style or the
+// fallback list. OpenType fonts with CFF outlines are refused, as they
+// cannot be embedded.
+func (a *App) ImportFont(path string) (string, error) {
+	if a.config == nil {
+		return "", fmt.Errorf("config not initialized")
+	}
+	name := filepath.Base(path)
+	if !stamp.IsFontFile(name) {
+		return "", fmt.Errorf("%s is not a .ttf or .otf font", name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := engine.CheckFont(data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	name, err = importFile(a.config.FontDir(), name, data)
+	if err != nil {
+		return "", err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Font imported: %s", name))
+	}
+	return name, nil
+}
+
+// GetFonts returns the file names of the imported fonts
+func (a *App) GetFonts() ([]string, error) {
+	if a.config == nil {
+		return nil, fmt.Errorf("config not initialized")
+	}
+	entries, err := os.ReadDir(a.config.FontDir())
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fonts := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && stamp.IsFontFile(e.Name()) {
+			fonts = append(fonts, e.Name())
+		}
+	}
+	return fonts, nil
+}
+
+// SetFontFallback sets the imported fonts tried, in order, for characters
+// missing in a stamp's font
+func (a *App) SetFontFallback(fonts []string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateFontFallback(fonts); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Font fallback updated to: %v", fonts))
+	}
+	return nil
+}
+
+// SetOverlayImage sets the image stamp drawn next to the overlay text when
+// no stamp set is in use: file (see ImportStampImage), size in mm, page
+// scope, placement, opacity and rotation. An empty file turns it off.
+func (a *App) SetOverlayImage(layer stamp.Layer) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	return a.config.UpdateOverlayImage(layer)
+}
+
+// SetOverlayCode sets the QR code or Code 128 barcode drawn with the
+// overlay text when no stamp set is in use. It encodes the rendered
+// template, e.g. "{serial}" or a verification URL such as
+// "https://docs.example.com/verify/{serial}". An empty type turns it off.
+func (a *App) SetOverlayCode(layer stamp.Layer) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateOverlayCode(layer); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Code stamp updated to: %s %q", layer.Code.Type, layer.Template))
+	}
+	return nil
+}
+
+// SetWatermark sets the watermark drawn across the pages when no stamp set
+// is in use: text, angle, size as a share of the page, opacity, color and
+// pages. Empty text turns it off.
+func (a *App) SetWatermark(wm stamp.Watermark) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateWatermark(wm); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Watermark updated to: %+v", wm))
+	}
+	return nil
+}
+
+// SetBates sets the Bates numbering of every page: document or global
+// mode, prefix, start, padding, position and style. An empty mode turns it
+// off.
+func (a *App) SetBates(b stamp.Bates) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateBates(b); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Bates numbering updated to: %+v", b))
+	}
+	return nil
+}
+
+// SetBands sets the header and footer bands, in mm, added above and below
+// every page so stamps anchored to the top or bottom sit there instead of
+// over the content. Zero heights turn them off.
+func (a *App) SetBands(b stamp.Bands) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateBands(b); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Bands updated to: %+v", b))
+	}
+	return nil
+}
+
+// SetAuditSheet sets the page summarizing the freeze that is added to
+// each output: cover or trailer position, title, rows and style. An empty
+// position turns it off.
+func (a *App) SetAuditSheet(sheet stamp.AuditSheet) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateAuditSheet(sheet); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Audit sheet updated to: %s", sheet.Position))
+	}
+	return nil
+}
+
+// SelectLetterhead lets the user pick a template PDF and imports it, see
+// ImportLetterhead; it returns the file name, or "" if cancelled
+func (a *App) SelectLetterhead() (string, error) {
+	app := application.Get()
+
+	dialog := app.Dialog.OpenFile()
+	dialog.SetTitle("Select Letterhead")
+	dialog.AddFilter("PDF Files", "*.pdf")
+	if currentWindow := app.Window.Current(); currentWindow != nil {
+		dialog.AttachToWindow(currentWindow)
+	}
+
+	file, err := dialog.PromptForSingleSelection()
+	if err != nil || file == "" {
+		return "", err
+	}
+	return a.ImportLetterhead(file)
+}
+
+// ImportLetterhead copies a template PDF into the stamp image folder of
+// the config dir and returns the file name to reference from the
+// letterhead
+func (a *App) ImportLetterhead(path string) (string, error) {
+	if a.config == nil {
+		return "", fmt.Errorf("config not initialized")
+	}
+	if err := engine.CheckTemplate(path); err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := importFile(a.config.ImageDir(), filepath.Base(path), data)
+	if err != nil {
+		return "", err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Letterhead imported: %s", name))
+	}
+	return name, nil
+}
+
+// SetLetterhead sets the template PDF page drawn under or over the pages
+// of each output and how it is scaled. An empty file turns it off.
+func (a *App) SetLetterhead(l stamp.Letterhead) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateLetterhead(l); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Letterhead updated to: %s", l.File))
+	}
+	return nil
+}
+
+// GetStampSets returns the saved stamp sets
+func (a *App) GetStampSets() []stamp.Set {
+	if a.config == nil {
+		return nil
+	}
+	return a.config.Current.StampSets
+}
+
+// SaveStampSet stores a named list of stamp layers, each with its own text
+// template, page scope, placement and style, replacing a set of the same
+// name
+func (a *App) SaveStampSet(set stamp.Set) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.SaveStampSet(set); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Stamp set saved: %s (%d layers)", set.Name, len(set.Layers)))
+	}
+	return nil
+}
+
+// DeleteStampSet removes a saved stamp set
+func (a *App) DeleteStampSet(name string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	return a.config.DeleteStampSet(name)
+}
+
+// UseStampSet selects the stamp set applied to new documents; empty uses
+// the single overlay settings
+func (a *App) UseStampSet(name string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UseStampSet(name); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Stamp set in use: %q", name))
+	}
+	return nil
+}
+
+// SetOverlayPages selects the pages that carry the overlay: first, last,
+// all, odd, even or page ranges such as "1-3,7,10-"
+func (a *App) SetOverlayPages(spec string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateOverlayPages(spec); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Overlay pages updated to: %s", spec))
+	}
+	return nil
+}
+
+// SetOperator sets the operator name stamped by {operator}; empty uses the
+// OS user
+func (a *App) SetOperator(name string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	return a.config.UpdateOperator(name)
+}
+
 // SetOverlayPosition updates the serial number position
 func (a *App) SetOverlayPosition(pos string) error {
 	if a.config == nil {
@@ -246,6 +1243,21 @@ func (a *App) SetOverlayPosition(pos string) error {
 	return a.config.UpdateOverlayPosition(pos)
 }
 
+// SetOverlayOffset sets the overlay distance from the anchored page edges
+// in pt or mm
+func (a *App) SetOverlayOffset(x, y float64, unit string) error {
+	if a.config == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	if err := a.config.UpdateOverlayOffset(x, y, unit); err != nil {
+		return err
+	}
+	if a.logger != nil {
+		a.logger.Info(fmt.Sprintf("Overlay offset updated to: %g, %g %s", x, y, unit))
+	}
+	return nil
+}
+
 // SetCompressionLevel updates the compression level setting
 func (a *App) SetCompressionLevel(level string) error {
 	if a.config == nil {
@@ -1,7 +1,12 @@
 package app
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"pdf-freezer/internal/config"
 )
 
 func TestNewApp(t *testing.T) {
@@ -26,8 +31,18 @@ func TestOnFileDrop(t *testing.T) {
 
 func TestCounterOverride(t *testing.T) {
 	app := NewApp()
+	hash, err := config.HashPIN("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.config.Current.AdminPINHash = hash
+
+	if err := app.SetNumberOverride(100, "test", "0000", true); err == nil {
+		t.Error("Override with a wrong PIN was accepted")
+	}
+
 	// Set override
-	err := app.SetNumberOverride(100)
+	err = app.SetNumberOverride(100, "test", "1234", true)
 	if err != nil {
 		t.Fatalf("SetNumberOverride failed: %v", err)
 	}
@@ -54,3 +69,56 @@ func TestPrefixConfig(t *testing.T) {
 		t.Errorf("Expected prefix TEST, got %s", cfg.Prefix)
 	}
 }
+
+func TestTestModeUsesSandboxCounter(t *testing.T) {
+	app := NewApp()
+	hash, err := config.HashPIN("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.config.Current.AdminPINHash = hash
+
+	before, err := app.GetCurrentNumber()
+	if err != nil {
+		t.Fatalf("GetCurrentNumber failed: %v", err)
+	}
+	if err := app.SetTestMode(true); err != nil {
+		t.Fatalf("SetTestMode failed: %v", err)
+	}
+	if err := app.SetNumberOverride(before+500, "training", "1234", true); err != nil {
+		t.Fatalf("SetNumberOverride in test mode failed: %v", err)
+	}
+	if serial, _ := app.GetCurrentSerial(); !strings.HasPrefix(serial, "TEST ") {
+		t.Errorf("Expected test marker in %q", serial)
+	}
+	if err := app.SetTestMode(false); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := app.GetCurrentNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Errorf("Test mode changed the production counter from %d to %d", before, after)
+	}
+}
+
+func TestImportFileKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	if name, err := importFile(dir, "logo.png", []byte("first")); err != nil || name != "logo.png" {
+		t.Fatalf("Expected logo.png, got %q (%v)", name, err)
+	}
+	// Another file of the same name must not replace the one stamps use
+	name, err := importFile(dir, "logo.png", []byte("second"))
+	if err != nil || name != "logo-2.png" {
+		t.Fatalf("Expected logo-2.png, got %q (%v)", name, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "logo.png")); string(data) != "first" {
+		t.Errorf("logo.png was replaced with %q", data)
+	}
+	// The same file again is not copied twice
+	if name, err := importFile(dir, "logo.png", []byte("second")); err != nil || name != "logo-2.png" {
+		t.Errorf("Expected logo-2.png again, got %q (%v)", name, err)
+	}
+}